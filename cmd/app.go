@@ -1,25 +1,55 @@
 package main
 
 import (
-	"fmt"
+	"context"
+	"errors"
 	"github.com/joho/godotenv"
+	"github.com/lelouchhh/friendly-basketball-reward/internal/api"
+	"github.com/lelouchhh/friendly-basketball-reward/internal/award"
 	"github.com/lelouchhh/friendly-basketball-reward/internal/config"
 	"github.com/lelouchhh/friendly-basketball-reward/internal/cron"
 	"github.com/lelouchhh/friendly-basketball-reward/internal/postgres"
 	"log"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 )
 
 func main() {
 	log.Println("Getting config")
 	err := godotenv.Load(".env")
-	fmt.Println(os.Getenv("POSTGRES_CONNECTION"))
 	if err != nil {
 		return
 	}
 	cfg := config.NewConfig()
 
-	db, err := postgres.NewDB(cfg.PostgresConn)
+	tieBreaks, err := award.ParseTieBreaks(cfg.TieBreaks)
+	if err != nil {
+		log.Fatal(err)
+	}
+	estimators, err := award.ParseEstimators(cfg.WinrateEstimators)
+	if err != nil {
+		log.Fatal(err)
+	}
+	awardCfg := award.Config{
+		TieBreaks:       tieBreaks,
+		PodiumSize:      cfg.PodiumSize,
+		Estimators:      estimators,
+		WinrateMinGames: cfg.WinrateMinGames,
+	}
+	if err := award.Configure(awardCfg); err != nil {
+		log.Fatal(err)
+	}
+
+	// Пустой LEAGUE_TIMEZONE означает UTC
+	loc, err := time.LoadLocation(cfg.Timezone)
+	if err != nil {
+		log.Fatalf("Invalid LEAGUE_TIMEZONE: %v", err)
+	}
+
+	db, err := postgres.NewDB(cfg.PostgresConn, loc)
 	log.Println("Connected to database")
 
 	if err != nil {
@@ -27,9 +57,92 @@ func main() {
 		return
 	}
 	defer db.Close()
+
+	// Корневой контекст отменяется по SIGINT/SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if len(os.Args) > 1 && os.Args[1] == "backfill" {
+		if err := runBackfill(ctx, db, loc, os.Args[2:]); err != nil {
+			log.Printf("Backfill failed: %v", err)
+			stop()
+			db.Close()
+			os.Exit(1)
+		}
+		return
+	}
+
 	log.Println("starting cron job")
-	fmt.Println(os.Getenv(""))
-	go cron.StartCronJobs(db, cfg.CronSpec)
-	select {}
+	schedules := map[postgres.Granularity]string{postgres.GranularityMonth: cfg.CronSpec}
+	for name, spec := range cfg.Schedules {
+		granularity, err := postgres.ParseGranularity(name)
+		if err != nil {
+			log.Print(err)
+			return
+		}
+		// Границы сезона задаются явно, поэтому сезон нельзя считать по расписанию
+		if !granularity.Calendar() {
+			log.Printf("Invalid CRON_SCHEDULES: %s awards cannot be scheduled, compute them with backfill", granularity)
+			return
+		}
+		schedules[granularity] = spec
+	}
+	// Досчет пропущенных периодов идет в фоне и не задерживает запуск API и
+	// cron; при остановке он прерывается, а незавершенная транзакция откатывается
+	catchUpDone := make(chan struct{})
+	if cfg.CatchUpPeriods > 0 {
+		granularities := make([]postgres.Granularity, 0, len(schedules))
+		for granularity := range schedules {
+			granularities = append(granularities, granularity)
+		}
+		log.Printf("checking last %d periods for missed awards", cfg.CatchUpPeriods)
+		go func() {
+			defer close(catchUpDone)
+			cron.CatchUp(ctx, db, granularities, cfg.CatchUpPeriods, loc)
+		}()
+	} else {
+		close(catchUpDone)
+	}
+
+	// Расчеты не прерываются сигналом сразу: при остановке им дается
+	// ShutdownTimeout, чтобы завершить и зафиксировать транзакцию.
+	scheduler, err := cron.StartCronJobs(context.WithoutCancel(ctx), db, schedules, loc)
+	if err != nil {
+		log.Print(err)
+		return
+	}
 
+	var srv *http.Server
+	if cfg.HTTPAddr != "" {
+		log.Printf("starting HTTP API on %s", cfg.HTTPAddr)
+		// Пересчеты по запросу, как и cron-задачи, при остановке завершаются в пределах ShutdownTimeout
+		srv = &http.Server{Addr: cfg.HTTPAddr, Handler: api.NewServer(context.WithoutCancel(ctx), db, loc, cfg.AdminToken)}
+		go func() {
+			if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Printf("HTTP API stopped: %v", err)
+				stop()
+			}
+		}()
+	}
+
+	<-ctx.Done()
+	log.Printf("Shutting down, waiting up to %s for running jobs", cfg.ShutdownTimeout)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+
+	if srv != nil {
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			log.Printf("Failed to stop HTTP API gracefully: %v", err)
+		}
+	}
+	if err := scheduler.Stop(shutdownCtx); err != nil {
+		log.Printf("Failed to stop cron jobs gracefully: %v", err)
+	}
+	select {
+	case <-catchUpDone:
+	case <-shutdownCtx.Done():
+		log.Println("Catch-up did not stop in time")
+	}
+	log.Println("Shutdown complete")
 }
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/lelouchhh/friendly-basketball-reward/internal/award"
+	"github.com/lelouchhh/friendly-basketball-reward/internal/postgres"
+)
+
+// backfillRow — итог расчета одной награды за один период.
+type backfillRow struct {
+	Period    string            `json:"period"`
+	Award     string            `json:"award"`
+	Status    string            `json:"status"`
+	Winners   []postgres.Winner `json:"winners"`
+	RunnersUp []postgres.Winner `json:"runners_up,omitempty"`
+	Error     string            `json:"error,omitempty"`
+}
+
+// runBackfill пересчитывает награды за каждый период от --from до --to включительно:
+//
+//	app backfill --from 2024-09 --to 2025-03 --awards top-rating,top-winrate --dry-run
+//
+// Периоды задаются в формате postgres.ParsePeriod, поэтому так же можно
+// пересчитать недели (2025-W01), кварталы (2025-Q1) или годы (2024).
+// Награды, уже успешно посчитанные за период или считающиеся прямо сейчас,
+// пропускаются, пока не указан --force, поэтому после сбоя команду можно
+// просто запустить повторно. С --dry-run
+// ничего не сохраняется, а вместе с призерами выводятся --runners-up следующих мест;
+// --format json выводит результат в JSON вместо таблицы, а --locale выбирает
+// язык названий наград.
+func runBackfill(ctx context.Context, db *postgres.DB, loc *time.Location, args []string) error {
+	fs := flag.NewFlagSet("backfill", flag.ExitOnError)
+	fromFlag := fs.String("from", "", "first period to compute, e.g. 2024-09")
+	toFlag := fs.String("to", "", "last period to compute, defaults to --from")
+	awardsFlag := fs.String("awards", "", "comma-separated award keys, all awards by default")
+	dryRun := fs.Bool("dry-run", false, "compute winners without saving them")
+	force := fs.Bool("force", false, "recompute awards that already completed successfully")
+	runnersUp := fs.Int("runners-up", 2, "places after the podium to show with --dry-run")
+	format := fs.String("format", "table", "output format: table or json")
+	locale := fs.String("locale", postgres.DefaultLocale, "language of award titles, e.g. ru or en")
+	_ = fs.Parse(args)
+
+	if *format != "table" && *format != "json" {
+		return fmt.Errorf("unknown --format %q", *format)
+	}
+
+	if *fromFlag == "" {
+		fs.Usage()
+		return errors.New("--from is required")
+	}
+	from, err := postgres.ParsePeriod(*fromFlag, loc)
+	if err != nil {
+		return fmt.Errorf("invalid --from: %w", err)
+	}
+	to := from
+	if *toFlag != "" {
+		if to, err = postgres.ParsePeriod(*toFlag, loc); err != nil {
+			return fmt.Errorf("invalid --to: %w", err)
+		}
+	}
+	if from.Granularity != to.Granularity {
+		return fmt.Errorf("--from and --to must have the same granularity, got %s and %s", from.Granularity, to.Granularity)
+	}
+	if to.Start.Before(from.Start) {
+		return errors.New("--to must not be before --from")
+	}
+
+	var keys []string
+	if *awardsFlag != "" {
+		for _, key := range strings.Split(*awardsFlag, ",") {
+			keys = append(keys, strings.TrimSpace(key))
+		}
+	}
+	awards, err := award.Select(keys)
+	if err != nil {
+		return err
+	}
+
+	// Сезоны считаются подряд с длительностью --from, поэтому --to должен
+	// совпасть с одним из шагов, иначе диапазон задан неверно
+	periods, err := postgres.PeriodRange(from, to)
+	if err != nil {
+		return fmt.Errorf("invalid --to: %w", err)
+	}
+
+	var rows []backfillRow
+	var failed bool
+	for _, period := range periods {
+		pending := awards
+		if !*force && !*dryRun {
+			if pending, err = award.Pending(ctx, db, period, awards); err != nil {
+				return err
+			}
+			isPending := make(map[string]bool, len(pending))
+			for _, a := range pending {
+				isPending[a.Key()] = true
+			}
+			for _, a := range awards {
+				if !isPending[a.Key()] {
+					rows = append(rows, backfillRow{Period: period.String(), Award: a.Key(), Status: "skipped"})
+				}
+			}
+		}
+		if len(pending) == 0 {
+			continue
+		}
+
+		pendingKeys := make([]string, 0, len(pending))
+		for _, a := range pending {
+			pendingKeys = append(pendingKeys, a.Key())
+		}
+		opts := award.Options{Awards: pendingKeys, DryRun: *dryRun, RunnersUp: *runnersUp}
+		report, err := award.ComputePeriod(ctx, db, period, opts)
+		failed = failed || err != nil
+		for _, res := range report.Results {
+			row := backfillRow{Period: period.String(), Award: res.Award, Winners: res.Winners, RunnersUp: res.RunnersUp}
+			switch {
+			case res.Err != nil:
+				row.Status = "failed"
+				row.Error = res.Err.Error()
+			case err != nil:
+				row.Status = "rolled back"
+			case *dryRun:
+				row.Status = "dry run"
+			default:
+				row.Status = "ok"
+			}
+			rows = append(rows, row)
+		}
+	}
+
+	if err := setTitles(ctx, db, rows, *locale); err != nil {
+		return err
+	}
+
+	if *format == "json" {
+		if err := printBackfillJSON(rows); err != nil {
+			return err
+		}
+	} else if *dryRun {
+		printPreview(rows)
+	} else {
+		printBackfill(rows)
+	}
+	if failed {
+		return errors.New("some periods failed, rerun the same command to resume")
+	}
+	return nil
+}
+
+// setTitles заполняет названия наград победителей на языке locale. Для типов
+// наград, которых еще нет в базе, используется postgres.RewardTypeTitle.
+func setTitles(ctx context.Context, db *postgres.DB, rows []backfillRow, locale string) error {
+	types, err := db.RewardTypes(ctx, locale)
+	if err != nil {
+		return err
+	}
+	titles := make(map[string]string, len(types))
+	for _, t := range types {
+		titles[t.Code] = t.Type
+	}
+
+	setTitle := func(winners []postgres.Winner) {
+		for i, w := range winners {
+			if title, ok := titles[w.RewardType]; ok {
+				winners[i].Title = title
+			} else {
+				winners[i].Title = postgres.RewardTypeTitle(w.RewardType)
+			}
+		}
+	}
+	for _, r := range rows {
+		setTitle(r.Winners)
+		setTitle(r.RunnersUp)
+	}
+	return nil
+}
+
+// printBackfill выводит итоговую таблицу backfill.
+func printBackfill(rows []backfillRow) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PERIOD\tAWARD\tSTATUS\tWINNERS\tERROR")
+	for _, r := range rows {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\n", r.Period, r.Award, r.Status, len(r.Winners), r.Error)
+	}
+	w.Flush()
+}
+
+// printPreview выводит таблицу будущих призеров и следующих за ними игроков.
+func printPreview(rows []backfillRow) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PERIOD\tAWARD\tREWARD\tPLACE\tUSER\tVALUE")
+	for _, r := range rows {
+		if r.Error != "" {
+			fmt.Fprintf(w, "%s\t%s\t%s\t\t\t%s\n", r.Period, r.Award, r.Status, r.Error)
+			continue
+		}
+		for _, winner := range r.Winners {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%d\t%s\n", r.Period, r.Award, winner.Title, winner.Rank, winner.UserID, formatValue(winner))
+		}
+		for _, next := range r.RunnersUp {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%d (runner-up)\t%d\t%s\n", r.Period, r.Award, next.Title, next.Rank, next.UserID, formatValue(next))
+		}
+	}
+	w.Flush()
+}
+
+// formatValue выводит значение награды вместе с единицей измерения и,
+// если они известны, победами и поражениями: "0.786 ratio (45-5)".
+func formatValue(w postgres.Winner) string {
+	value := strconv.FormatFloat(w.Value, 'f', -1, 64)
+	if w.Unit == postgres.UnitRatio {
+		value = strconv.FormatFloat(w.Value, 'f', 3, 64)
+	}
+	if w.Unit != "" {
+		value += " " + w.Unit
+	}
+	if w.Details.Wins != nil && w.Details.Losses != nil {
+		value += fmt.Sprintf(" (%d-%d)", *w.Details.Wins, *w.Details.Losses)
+	}
+	return value
+}
+
+// printBackfillJSON выводит результат backfill в JSON.
+func printBackfillJSON(rows []backfillRow) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if rows == nil {
+		rows = []backfillRow{}
+	}
+	return enc.Encode(rows)
+}
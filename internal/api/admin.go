@@ -0,0 +1,114 @@
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/lelouchhh/friendly-basketball-reward/internal/award"
+	"github.com/lelouchhh/friendly-basketball-reward/internal/postgres"
+)
+
+// recomputeTimeout — сколько может длиться пересчет по запросу администратора.
+const recomputeTimeout = 10 * time.Minute
+
+// recomputeRequest — тело запроса POST /admin/recompute.
+type recomputeRequest struct {
+	Period    string   `json:"period"`
+	Awards    []string `json:"awards"`
+	DryRun    bool     `json:"dry_run"`
+	RunnersUp int      `json:"runners_up"`
+}
+
+// recomputeResult — итог пересчета одной награды.
+type recomputeResult struct {
+	Award     string            `json:"award"`
+	Winners   []postgres.Winner `json:"winners"`
+	RunnersUp []postgres.Winner `json:"runners_up,omitempty"`
+	Error     string            `json:"error,omitempty"`
+}
+
+// recomputeResponse — итог пересчета за период.
+type recomputeResponse struct {
+	Period    string            `json:"period"`
+	DryRun    bool              `json:"dry_run"`
+	Committed bool              `json:"committed"`
+	Results   []recomputeResult `json:"results"`
+	Error     string            `json:"error,omitempty"`
+}
+
+// handleRecompute пересчитывает награды за произвольный период тем же
+// конвейером, что и cron-задача.
+func (s *Server) handleRecompute(w http.ResponseWriter, r *http.Request) {
+	var req recomputeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+	period, err := postgres.ParsePeriod(req.Period, s.loc)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if _, err := award.Select(req.Awards); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if req.RunnersUp < 0 {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("runners_up must not be negative, got %d", req.RunnersUp))
+		return
+	}
+
+	// Пересчет не зависит от соединения с клиентом: иначе при разрыве
+	// транзакция откатится, а журнал запусков останется незавершенным
+	ctx, cancel := context.WithTimeout(s.ctx, recomputeTimeout)
+	defer cancel()
+	report, err := award.ComputePeriod(ctx, s.db, period, award.Options{Awards: req.Awards, DryRun: req.DryRun, RunnersUp: req.RunnersUp})
+
+	resp := recomputeResponse{
+		Period:    period.String(),
+		DryRun:    req.DryRun,
+		Committed: err == nil && !req.DryRun,
+		Results:   make([]recomputeResult, 0, len(report.Results)),
+	}
+	for _, res := range report.Results {
+		item := recomputeResult{Award: res.Award, Winners: res.Winners, RunnersUp: res.RunnersUp}
+		if item.Winners == nil {
+			item.Winners = []postgres.Winner{}
+		}
+		if res.Err != nil {
+			item.Error = res.Err.Error()
+		}
+		resp.Results = append(resp.Results, item)
+	}
+
+	status := http.StatusOK
+	if err != nil {
+		resp.Error = err.Error()
+		status = http.StatusInternalServerError
+	}
+	writeJSON(w, status, resp)
+}
+
+// requireAdmin пропускает только запросы с заголовком Authorization: Bearer <токен администратора>.
+// Без настроенного токена административные методы отключены.
+func (s *Server) requireAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.adminToken == "" {
+			writeError(w, http.StatusForbidden, errors.New("admin API is disabled"))
+			return
+		}
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.adminToken)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeError(w, http.StatusUnauthorized, errors.New("unauthorized"))
+			return
+		}
+		next(w, r)
+	}
+}
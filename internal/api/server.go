@@ -0,0 +1,220 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/lelouchhh/friendly-basketball-reward/internal/postgres"
+)
+
+// Server отдает сохраненные награды по HTTP в формате JSON.
+type Server struct {
+	ctx        context.Context
+	db         *postgres.DB
+	loc        *time.Location
+	adminToken string
+	mux        *http.ServeMux
+}
+
+// NewServer создает HTTP-обработчик API наград. Периоды в запросах
+// интерпретируются в часовом поясе лиги loc. Административные методы
+// доступны только с токеном adminToken; пустой токен их отключает.
+// Пересчеты выполняются в контексте сервера ctx, а не запроса, чтобы разрыв
+// соединения с клиентом не прерывал расчет.
+func NewServer(ctx context.Context, db *postgres.DB, loc *time.Location, adminToken string) *Server {
+	s := &Server{ctx: ctx, db: db, loc: loc, adminToken: adminToken, mux: http.NewServeMux()}
+
+	s.mux.HandleFunc("GET /awards", s.handleAwards)
+	s.mux.HandleFunc("GET /users/{id}/awards", s.handleUserAwards)
+	s.mux.HandleFunc("GET /award-types", s.handleAwardTypes)
+	s.mux.HandleFunc("GET /leaderboard", s.handleLeaderboard)
+	s.mux.HandleFunc("GET /streak-records", s.handleStreakRecords)
+	s.mux.HandleFunc("POST /admin/recompute", s.requireAdmin(s.handleRecompute))
+
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// handleAwards отдает награды за период: ?year=2025&month=03 или ?period=2025-Q1.
+// Язык названий наград выбирается параметром locale, см. localeFromQuery.
+func (s *Server) handleAwards(w http.ResponseWriter, r *http.Request) {
+	period, err := s.periodFromQuery(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if period == nil {
+		writeError(w, http.StatusBadRequest, errors.New("period or year and month are required"))
+		return
+	}
+	locale, err := localeFromQuery(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	rewards, err := s.db.RewardsByPeriod(r.Context(), *period, locale)
+	if err != nil {
+		s.internalError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, rewards)
+}
+
+// handleUserAwards отдает все награды игрока.
+func (s *Server) handleUserAwards(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid user id %q", r.PathValue("id")))
+		return
+	}
+	locale, err := localeFromQuery(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	rewards, err := s.db.RewardsByUser(r.Context(), userID, locale)
+	if err != nil {
+		s.internalError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, rewards)
+}
+
+// handleAwardTypes отдает все типы наград.
+func (s *Server) handleAwardTypes(w http.ResponseWriter, r *http.Request) {
+	locale, err := localeFromQuery(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	types, err := s.db.RewardTypes(r.Context(), locale)
+	if err != nil {
+		s.internalError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, types)
+}
+
+// handleLeaderboard отдает игроков по количеству медалей, за всё время или за
+// период. Медали считаются за награды одной длительности, заданной параметром
+// granularity, по умолчанию month: ?period=2025&granularity=week.
+func (s *Server) handleLeaderboard(w http.ResponseWriter, r *http.Request) {
+	period, err := s.periodFromQuery(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	granularity := postgres.GranularityMonth
+	if name := r.URL.Query().Get("granularity"); name != "" {
+		if granularity, err = postgres.ParseGranularity(name); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	entries, err := s.db.Leaderboard(r.Context(), period, granularity)
+	if err != nil {
+		s.internalError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, entries)
+}
+
+// handleStreakRecords отдает личные рекорды серий игроков за всё время:
+// ?kind=wins&game_type=1x1. Вид серии — wins, losses или unbeaten, по умолчанию
+// wins; без game_type отдаются серии по всем играм.
+func (s *Server) handleStreakRecords(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	kind := postgres.StreakWins
+	if name := q.Get("kind"); name != "" {
+		var err error
+		if kind, err = postgres.ParseStreakKind(name); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	records, err := s.db.StreakRecords(r.Context(), kind, q.Get("game_type"))
+	if err != nil {
+		s.internalError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, records)
+}
+
+// periodFromQuery читает период из параметров period или year и month.
+// Возвращает nil, если период не задан.
+func (s *Server) periodFromQuery(r *http.Request) (*postgres.Period, error) {
+	q := r.URL.Query()
+
+	raw := q.Get("period")
+	if raw == "" {
+		year, month := q.Get("year"), q.Get("month")
+		switch {
+		case year == "" && month == "":
+			return nil, nil
+		case month == "":
+			raw = year
+		case year == "":
+			return nil, errors.New("year is required with month")
+		default:
+			m, err := strconv.Atoi(month)
+			if err != nil {
+				return nil, fmt.Errorf("invalid month %q", month)
+			}
+			raw = fmt.Sprintf("%s-%02d", year, m)
+		}
+	}
+
+	period, err := postgres.ParsePeriod(raw, s.loc)
+	if err != nil {
+		return nil, err
+	}
+	return &period, nil
+}
+
+var localePattern = regexp.MustCompile(`^[a-z]{2}$`)
+
+// localeFromQuery читает язык названий наград из параметра locale, например
+// ?locale=en. Без параметра используется postgres.DefaultLocale. Если перевода
+// на выбранный язык нет, отдается исходное название награды.
+func localeFromQuery(r *http.Request) (string, error) {
+	locale := r.URL.Query().Get("locale")
+	if locale == "" {
+		return postgres.DefaultLocale, nil
+	}
+	if !localePattern.MatchString(locale) {
+		return "", fmt.Errorf("invalid locale %q", locale)
+	}
+	return locale, nil
+}
+
+func (s *Server) internalError(w http.ResponseWriter, err error) {
+	log.Printf("API request failed: %v", err)
+	writeError(w, http.StatusInternalServerError, errors.New("internal error"))
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("Failed to write response: %v", err)
+	}
+}
@@ -0,0 +1,70 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestServer_BadRequests(t *testing.T) {
+	tests := []struct {
+		name       string
+		target     string
+		wantStatus int
+	}{
+		{name: "awards without period", target: "/awards", wantStatus: http.StatusBadRequest},
+		{name: "awards with month only", target: "/awards?month=03", wantStatus: http.StatusBadRequest},
+		{name: "awards with invalid month", target: "/awards?year=2025&month=march", wantStatus: http.StatusBadRequest},
+		{name: "awards with invalid locale", target: "/awards?period=2025-03&locale=english", wantStatus: http.StatusBadRequest},
+		{name: "user awards with invalid id", target: "/users/abc/awards", wantStatus: http.StatusBadRequest},
+		{name: "user awards with invalid locale", target: "/users/1/awards?locale=EN", wantStatus: http.StatusBadRequest},
+		{name: "award types with invalid locale", target: "/award-types?locale=e", wantStatus: http.StatusBadRequest},
+		{name: "leaderboard with invalid period", target: "/leaderboard?period=soon", wantStatus: http.StatusBadRequest},
+		{name: "leaderboard with invalid granularity", target: "/leaderboard?granularity=day", wantStatus: http.StatusBadRequest},
+		{name: "streak records with unknown kind", target: "/streak-records?kind=draws", wantStatus: http.StatusBadRequest},
+		{name: "unknown route", target: "/unknown", wantStatus: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			NewServer(context.Background(), nil, time.UTC, "").ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.target, nil))
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %v, want %v", rec.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestServer_RecomputeRejects(t *testing.T) {
+	tests := []struct {
+		name       string
+		adminToken string
+		auth       string
+		body       string
+		wantStatus int
+	}{
+		{name: "admin disabled", adminToken: "", auth: "Bearer secret", body: `{"period":"2025-03"}`, wantStatus: http.StatusForbidden},
+		{name: "missing token", adminToken: "secret", auth: "", body: `{"period":"2025-03"}`, wantStatus: http.StatusUnauthorized},
+		{name: "wrong token", adminToken: "secret", auth: "Bearer wrong", body: `{"period":"2025-03"}`, wantStatus: http.StatusUnauthorized},
+		{name: "invalid body", adminToken: "secret", auth: "Bearer secret", body: `{`, wantStatus: http.StatusBadRequest},
+		{name: "invalid period", adminToken: "secret", auth: "Bearer secret", body: `{"period":"soon"}`, wantStatus: http.StatusBadRequest},
+		{name: "unknown award", adminToken: "secret", auth: "Bearer secret", body: `{"period":"2025-03","awards":["unknown"]}`, wantStatus: http.StatusBadRequest},
+		{name: "negative runners-up", adminToken: "secret", auth: "Bearer secret", body: `{"period":"2025-03","runners_up":-1}`, wantStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/admin/recompute", strings.NewReader(tt.body))
+			if tt.auth != "" {
+				req.Header.Set("Authorization", tt.auth)
+			}
+			rec := httptest.NewRecorder()
+			NewServer(context.Background(), nil, time.UTC, tt.adminToken).ServeHTTP(rec, req)
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %v, want %v", rec.Code, tt.wantStatus)
+			}
+		})
+	}
+}
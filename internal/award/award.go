@@ -0,0 +1,342 @@
+package award
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/lelouchhh/friendly-basketball-reward/internal/postgres"
+)
+
+// Award описывает награду, которую можно посчитать за период.
+type Award interface {
+	// Key — стабильный идентификатор награды, например "top-rating".
+	Key() string
+	// Name — человекочитаемое название награды.
+	Name() string
+	// RewardType — код типа награды, например postgres.TOP_WINRATE_MONTH.
+	// Награды по формату игры сохраняются под кодом с суффиксом формата.
+	RewardType() string
+	// Compute вычисляет победителей за период, ничего не сохраняя.
+	Compute(ctx context.Context, db *postgres.DB, period postgres.Period) ([]postgres.Winner, error)
+}
+
+// Previewer — награда, которая в режиме предпросмотра может показать не только
+// призеров, но и до runnersUp игроков, занявших места сразу за ними.
+type Previewer interface {
+	Preview(ctx context.Context, db *postgres.DB, period postgres.Period, runnersUp int) (winners, nextUp []postgres.Winner, err error)
+}
+
+// RecordAward — награда по личным рекордам серий за всё время. Перед ее
+// расчетом рекорды обновляются по конец периода, см.
+// postgres.DB.UpdateStreakRecords.
+type RecordAward interface {
+	NeedsStreakRecords() bool
+}
+
+var (
+	mu       sync.RWMutex
+	registry []Award
+	byKey    = make(map[string]Award)
+)
+
+// Register добавляет награду в реестр. Повторная регистрация ключа вызывает панику.
+func Register(a Award) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if _, exists := byKey[a.Key()]; exists {
+		panic(fmt.Sprintf("award: Register called twice for key %q", a.Key()))
+	}
+	registry = append(registry, a)
+	byKey[a.Key()] = a
+}
+
+// All возвращает все зарегистрированные награды в порядке регистрации.
+func All() []Award {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	awards := make([]Award, len(registry))
+	copy(awards, registry)
+	return awards
+}
+
+// Get возвращает награду по ключу.
+func Get(key string) (Award, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	a, ok := byKey[key]
+	return a, ok
+}
+
+// Result — итог расчета одной награды. RunnersUp заполняется только в режиме DryRun.
+type Result struct {
+	Award     string
+	Winners   []postgres.Winner
+	RunnersUp []postgres.Winner
+	Err       error
+}
+
+// Report — итог расчета всех наград за период.
+type Report struct {
+	Period  postgres.Period
+	Results []Result
+}
+
+// Err объединяет ошибки всех наград отчета. Возвращает nil, если ошибок не было.
+func (r Report) Err() error {
+	var errs []error
+	for _, res := range r.Results {
+		if res.Err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", res.Award, res.Err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Options — параметры одного расчета наград.
+type Options struct {
+	// Awards — ключи наград для расчета. Пустой список означает все награды.
+	Awards []string
+	// DryRun — посчитать победителей, ничего не сохраняя.
+	DryRun bool
+	// RunnersUp — сколько мест за призерами показать в режиме DryRun.
+	RunnersUp int
+}
+
+// Select возвращает награды по ключам в порядке регистрации.
+// Пустой список ключей означает все зарегистрированные награды.
+func Select(keys []string) ([]Award, error) {
+	if len(keys) == 0 {
+		return All(), nil
+	}
+
+	wanted := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		if _, ok := Get(key); !ok {
+			return nil, fmt.Errorf("unknown award %q", key)
+		}
+		wanted[key] = true
+	}
+
+	var awards []Award
+	for _, a := range All() {
+		if wanted[a.Key()] {
+			awards = append(awards, a)
+		}
+	}
+	return awards, nil
+}
+
+// StaleRunAfter — через сколько незавершенный запуск считается прерванным,
+// например упавшим вместе с процессом.
+const StaleRunAfter = time.Hour
+
+// Pending возвращает награды из awards, у которых за период нет успешного
+// запуска. Награда, которую прямо сейчас считает другой запуск, не считается
+// ожидающей, пока запуск не устарел, см. StaleRunAfter. Награда без записей в
+// журнале, например посчитанная до появления журнала запусков, считается
+// посчитанной, если за период сохранены ее победители.
+func Pending(ctx context.Context, db *postgres.DB, period postgres.Period, awards []Award) ([]Award, error) {
+	runs, err := db.LatestRuns(ctx, period)
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []Award
+	for _, a := range awards {
+		if run, ok := runs[a.Key()]; ok {
+			if runPending(run) {
+				pending = append(pending, a)
+			}
+			continue
+		}
+		saved, err := db.HasRewards(ctx, period, a.RewardType())
+		if err != nil {
+			return nil, err
+		}
+		if !saved {
+			pending = append(pending, a)
+		}
+	}
+	return pending, nil
+}
+
+// runPending сообщает, нужно ли досчитать награду после запуска run.
+func runPending(run postgres.LatestRun) bool {
+	switch run.Status {
+	case postgres.RunStatusSuccess:
+		return false
+	case postgres.RunStatusRunning:
+		return run.Age >= StaleRunAfter
+	default:
+		return true
+	}
+}
+
+// ComputePeriod вычисляет награды за период в одной транзакции. Если хотя бы
+// одна награда завершилась ошибкой, транзакция откатывается и за период не
+// сохраняется ничего. Если среди наград есть RecordAward, перед ними личные
+// рекорды серий обновляются по конец периода в отдельной транзакции. В режиме
+// DryRun победители только вычисляются, а рекорды не обновляются: предпросмотр
+// использует уже учтенные игры.
+func ComputePeriod(ctx context.Context, db *postgres.DB, period postgres.Period, opts Options) (Report, error) {
+	awards, err := Select(opts.Awards)
+	if err != nil {
+		return Report{Period: period}, err
+	}
+
+	log.Printf("Processing rewards for %s...", period)
+
+	report := Report{Period: period}
+	if !opts.DryRun && needsStreakRecords(awards) {
+		if err := db.UpdateStreakRecords(ctx, period.End); err != nil {
+			log.Printf("Failed to update streak records for %s: %v", period, err)
+			return report, err
+		}
+	}
+
+	var runIDs []int
+	inTx := db.InTx
+	if opts.DryRun {
+		inTx = db.InRollbackTx
+	}
+	err = inTx(ctx, func(tx *postgres.DB) error {
+		for _, a := range awards {
+			var runID int
+			if !opts.DryRun {
+				runID = startRun(ctx, db, period, a)
+			}
+			if runID != 0 {
+				runIDs = append(runIDs, runID)
+			}
+
+			// Каждая награда выполняется в своей точке сохранения, чтобы ошибка
+			// одной награды не мешала посчитать остальные для отчета.
+			res := Result{Award: a.Key()}
+			res.Err = tx.InTx(ctx, func(tx *postgres.DB) error {
+				var err error
+				if opts.DryRun {
+					res.Winners, res.RunnersUp, err = preview(ctx, tx, a, period, opts.RunnersUp)
+				} else {
+					res.Winners, err = process(ctx, tx, a, period)
+				}
+				return err
+			})
+			report.Results = append(report.Results, res)
+
+			finishRun(ctx, db, runID, res)
+		}
+		return report.Err()
+	})
+	if err != nil {
+		log.Printf("Failed to process rewards for %s, rolled back: %v", period, err)
+		if len(runIDs) > 0 {
+			if rbErr := db.RollbackRuns(ctx, runIDs); rbErr != nil {
+				log.Printf("Failed to update reward run ledger: %v", rbErr)
+			}
+		}
+		return report, err
+	}
+
+	log.Printf("Finished processing rewards for %s", period)
+	return report, nil
+}
+
+// needsStreakRecords сообщает, есть ли среди наград RecordAward.
+func needsStreakRecords(awards []Award) bool {
+	for _, a := range awards {
+		if r, ok := a.(RecordAward); ok && r.NeedsStreakRecords() {
+			return true
+		}
+	}
+	return false
+}
+
+// preview вычисляет призеров и следующих за ними игроков, ничего не сохраняя.
+func preview(ctx context.Context, db *postgres.DB, a Award, period postgres.Period, runnersUp int) (winners, nextUp []postgres.Winner, err error) {
+	log.Printf("Previewing %s for %s...", a.Name(), period)
+
+	if p, ok := a.(Previewer); ok && runnersUp > 0 {
+		winners, nextUp, err = p.Preview(ctx, db, period, runnersUp)
+	} else {
+		winners, err = a.Compute(ctx, db, period)
+	}
+	if err != nil {
+		log.Printf("Failed to preview %s for %s: %v", a.Name(), period, err)
+		return nil, nil, err
+	}
+	return winners, nextUp, nil
+}
+
+// process вычисляет награду за период и сохраняет всех победителей.
+func process(ctx context.Context, db *postgres.DB, a Award, period postgres.Period) ([]postgres.Winner, error) {
+	log.Printf("Processing %s for %s...", a.Name(), period)
+
+	winners, err := a.Compute(ctx, db, period)
+	if err != nil {
+		log.Printf("Failed to process %s for %s: %v", a.Name(), period, err)
+		return nil, err
+	}
+
+	// Пересчет заменяет прежних победителей всех типов награды, в том числе
+	// форматов, в которых победителей больше нет
+	if err := db.ClearRewards(ctx, period, a.RewardType()); err != nil {
+		log.Printf("Failed to process %s for %s: %v", a.Name(), period, err)
+		return nil, err
+	}
+
+	// Типы наград для новых форматов игр создаются при первом сохранении
+	ensured := make(map[string]bool)
+	for _, w := range winners {
+		if ensured[w.RewardType] {
+			continue
+		}
+		if err := db.EnsureRewardType(ctx, w.RewardType); err != nil {
+			log.Printf("Failed to process %s for %s: %v", a.Name(), period, err)
+			return nil, err
+		}
+		ensured[w.RewardType] = true
+	}
+
+	for _, w := range winners {
+		if _, err := db.SaveReward(ctx, period, w); err != nil {
+			log.Printf("Failed to process %s for %s: %v", a.Name(), period, err)
+			return nil, fmt.Errorf("failed to save reward %s: %w", w.RewardType, err)
+		}
+	}
+
+	log.Printf("Successfully processed %s for %s", a.Name(), period)
+	return winners, nil
+}
+
+// startRun записывает начало расчета в журнал. Ошибка журнала не прерывает
+// расчет наград, поэтому она только логируется, а runID остается нулевым.
+func startRun(ctx context.Context, db *postgres.DB, period postgres.Period, a Award) int {
+	runID, err := db.StartRun(ctx, period, a.Key())
+	if err != nil {
+		log.Printf("Failed to write reward run ledger for %s: %v", a.Key(), err)
+		return 0
+	}
+	return runID
+}
+
+// finishRun записывает итог расчета награды в журнал.
+func finishRun(ctx context.Context, db *postgres.DB, runID int, res Result) {
+	if runID == 0 {
+		return
+	}
+
+	status := postgres.RunStatusSuccess
+	if res.Err != nil {
+		status = postgres.RunStatusFailed
+	}
+	if err := db.FinishRun(ctx, runID, status, res.Err, len(res.Winners)); err != nil {
+		log.Printf("Failed to write reward run ledger for %s: %v", res.Award, err)
+	}
+}
@@ -0,0 +1,76 @@
+package award
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/lelouchhh/friendly-basketball-reward/internal/postgres"
+)
+
+func TestGet(t *testing.T) {
+	tests := []struct {
+		name   string
+		key    string
+		wantOk bool
+	}{
+		{name: "registered award", key: "top-rating", wantOk: true},
+		{name: "unknown award", key: "unknown", wantOk: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, ok := Get(tt.key)
+			if ok != tt.wantOk {
+				t.Fatalf("Get() ok = %v, want %v", ok, tt.wantOk)
+			}
+			if ok && a.Key() != tt.key {
+				t.Errorf("Get() key = %v, want %v", a.Key(), tt.key)
+			}
+		})
+	}
+}
+
+func TestReport_Err(t *testing.T) {
+	errFailed := errors.New("failed")
+	tests := []struct {
+		name    string
+		results []Result
+		wantErr bool
+	}{
+		{name: "no results", results: nil, wantErr: false},
+		{name: "all succeeded", results: []Result{{Award: "top-rating"}, {Award: "top-winrate"}}, wantErr: false},
+		{name: "one failed", results: []Result{{Award: "top-rating"}, {Award: "top-winrate", Err: errFailed}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Report{Results: tt.results}.Err()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Err() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr && !errors.Is(err, errFailed) {
+				t.Errorf("Err() = %v, want wrapped %v", err, errFailed)
+			}
+		})
+	}
+}
+
+func TestRunPending(t *testing.T) {
+	tests := []struct {
+		name string
+		run  postgres.LatestRun
+		want bool
+	}{
+		{name: "succeeded", run: postgres.LatestRun{Status: postgres.RunStatusSuccess}, want: false},
+		{name: "running now", run: postgres.LatestRun{Status: postgres.RunStatusRunning, Age: time.Minute}, want: false},
+		{name: "stale run", run: postgres.LatestRun{Status: postgres.RunStatusRunning, Age: StaleRunAfter}, want: true},
+		{name: "failed", run: postgres.LatestRun{Status: postgres.RunStatusFailed}, want: true},
+		{name: "rolled back", run: postgres.LatestRun{Status: postgres.RunStatusRolledBack}, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := runPending(tt.run); got != tt.want {
+				t.Errorf("runPending() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
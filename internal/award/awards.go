@@ -0,0 +1,107 @@
+package award
+
+import (
+	"context"
+
+	"github.com/lelouchhh/friendly-basketball-reward/internal/postgres"
+)
+
+// queryAward — награда, кандидаты которой вычисляются одним методом postgres.DB.
+// Метод получает код награды и не упорядочивает кандидатов: одни и те же
+// кандидаты ранжируются по order в пользу лучших или худших значений.
+type queryAward struct {
+	key        string
+	name       string
+	rewardType string
+	order      Order
+	tieBreak   TieBreak
+	unit       string
+	query      func(db *postgres.DB, ctx context.Context, period postgres.Period, rewardType string) ([]postgres.Candidate, error)
+}
+
+func (a queryAward) Key() string  { return a.key }
+func (a queryAward) Name() string { return a.name }
+
+func (a queryAward) RewardType() string { return a.rewardType }
+
+func (a queryAward) Compute(ctx context.Context, db *postgres.DB, period postgres.Period) ([]postgres.Winner, error) {
+	winners, _, err := a.Preview(ctx, db, period, 0)
+	return winners, err
+}
+
+// Preview возвращает призеров и до runnersUp следующих за ними мест.
+func (a queryAward) Preview(ctx context.Context, db *postgres.DB, period postgres.Period, runnersUp int) (winners, nextUp []postgres.Winner, err error) {
+	candidates, err := a.query(db, ctx, period, a.rewardType)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	podium, rest := a.rank(candidates, runnersUp)
+	for _, r := range podium {
+		winners = append(winners, a.winner(r))
+	}
+	for _, r := range rest {
+		nextUp = append(nextUp, a.winner(r))
+	}
+	return winners, nextUp, nil
+}
+
+// rank распределяет места среди кандидатов и делит их на призеров и до
+// runnersUp следующих за ними мест.
+func (a queryAward) rank(candidates []postgres.Candidate, runnersUp int) (podium, rest []Ranked) {
+	size := podiumSize()
+	for _, r := range Rank(candidates, a.order, tieBreakFor(a.key, a.tieBreak), size+runnersUp) {
+		if r.Rank <= size {
+			podium = append(podium, r)
+		} else {
+			rest = append(rest, r)
+		}
+	}
+	return podium, rest
+}
+
+// winner возвращает призера для занятого места. Для наград за серии игр в
+// подробностях сохраняются начало и конец серии.
+func (a queryAward) winner(r Ranked) postgres.Winner {
+	w := postgres.Winner{
+		UserID:     r.UserID,
+		RewardType: r.RewardType,
+		Rank:       r.Rank,
+		Value:      r.Value,
+		Unit:       a.unit,
+		Details:    postgres.RewardDetails{Games: r.Games, GameType: r.GameType},
+		Evidence:   r.GameIDs,
+	}
+	if !r.StartedAt.IsZero() {
+		startedAt, endedAt := r.StartedAt, r.AchievedAt
+		w.Details.StartedAt = &startedAt
+		w.Details.EndedAt = &endedAt
+	}
+	return w
+}
+
+// recordAward — награда за новый рекорд лиги. Кандидаты выбираются из личных
+// рекордов серий, поэтому перед расчетом рекорды обновляются по конец периода.
+type recordAward struct {
+	queryAward
+}
+
+func (recordAward) NeedsStreakRecords() bool { return true }
+
+// Встроенные награды. Новая награда добавляется сюда и автоматически
+// подхватывается cron-задачей и загрузкой прошлых месяцев.
+func init() {
+	Register(queryAward{"top-rating", "top rating", postgres.BEST_PLAYER_BY_RATING_MONTH, Desc, TieBreakShare, postgres.UnitRating, (*postgres.DB).FinalRatings})
+	Register(queryAward{"worst-rating", "worst rating", postgres.WORST_PLAYER_BY_RATING_MONTH, Asc, TieBreakShare, postgres.UnitRating, (*postgres.DB).FinalRatings})
+	Register(winrateAward{queryAward{"top-winrate", "top winrate", postgres.TOP_WINRATE_MONTH, Desc, TieBreakShare, postgres.UnitRatio, (*postgres.DB).Winrates}, EstimatorWilsonLower})
+	Register(winrateAward{queryAward{"bottom-winrate", "bottom winrate", postgres.MAX_LOSERATE_MONTH, Asc, TieBreakShare, postgres.UnitRatio, (*postgres.DB).Winrates}, EstimatorWilsonUpper})
+	Register(queryAward{"top-gained-rating", "top gained rating", postgres.TOP_GAINED_RATING_MONTH, Desc, TieBreakShare, postgres.UnitRatingPoints, (*postgres.DB).RatingChanges})
+	Register(queryAward{"top-lost-rating", "top lost rating", postgres.MAX_LOST_RATING_MONTH, Asc, TieBreakShare, postgres.UnitRatingPoints, (*postgres.DB).RatingChanges})
+	Register(queryAward{"max-games-played", "max games played", postgres.MAX_GAMES_PLAYED_MONTH, Desc, TieBreakShare, postgres.UnitGames, (*postgres.DB).MaxGamesPlayed})
+	Register(queryAward{"longest-win-streak", "longest win streak", postgres.LONGEST_WIN_STREAK_MONTH, Desc, TieBreakShare, postgres.UnitWins, (*postgres.DB).LongestWinStreak})
+	Register(queryAward{"longest-loss-streak", "longest loss streak", postgres.LONGEST_LOSS_STREAK_MONTH, Desc, TieBreakShare, postgres.UnitLosses, (*postgres.DB).LongestLossStreak})
+	Register(queryAward{"longest-unbeaten-run", "longest unbeaten run", postgres.LONGEST_UNBEATEN_RUN_MONTH, Desc, TieBreakShare, postgres.UnitGames, (*postgres.DB).LongestUnbeatenRun})
+	Register(queryAward{"current-win-streak", "current win streak", postgres.CURRENT_WIN_STREAK, Desc, TieBreakShare, postgres.UnitWins, (*postgres.DB).CurrentWinStreak})
+	Register(recordAward{queryAward{"win-streak-record", "win streak record", postgres.WIN_STREAK_RECORD, Desc, TieBreakShare, postgres.UnitWins, (*postgres.DB).WinStreakRecord}})
+	Register(recordAward{queryAward{"unbeaten-run-record", "unbeaten run record", postgres.UNBEATEN_RUN_RECORD, Desc, TieBreakShare, postgres.UnitGames, (*postgres.DB).UnbeatenRunRecord}})
+}
@@ -0,0 +1,53 @@
+package award
+
+import (
+	"context"
+	"testing"
+
+	"github.com/lelouchhh/friendly-basketball-reward/internal/postgres"
+)
+
+func TestQueryAward_Preview(t *testing.T) {
+	a := queryAward{
+		key:   "test",
+		name:  "test",
+		order: Desc,
+		unit:  postgres.UnitGames,
+		query: func(db *postgres.DB, ctx context.Context, period postgres.Period, rewardType string) ([]postgres.Candidate, error) {
+			return []postgres.Candidate{
+				{UserID: 1, RewardType: "t", Value: 50},
+				{UserID: 2, RewardType: "t", Value: 40},
+				{UserID: 3, RewardType: "t", Value: 30},
+				{UserID: 4, RewardType: "t", Value: 20},
+				{UserID: 5, RewardType: "t", Value: 10},
+			}, nil
+		},
+	}
+
+	tests := []struct {
+		name          string
+		runnersUp     int
+		wantWinners   int
+		wantRunnersUp int
+	}{
+		{name: "podium only", runnersUp: 0, wantWinners: DefaultPodiumSize, wantRunnersUp: 0},
+		{name: "with runners-up", runnersUp: 1, wantWinners: DefaultPodiumSize, wantRunnersUp: 1},
+		{name: "more runners-up than candidates", runnersUp: 10, wantWinners: DefaultPodiumSize, wantRunnersUp: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			winners, nextUp, err := a.Preview(context.Background(), nil, postgres.MonthPeriod(2025, 3), tt.runnersUp)
+			if err != nil {
+				t.Fatalf("Preview() error = %v", err)
+			}
+			if len(winners) != tt.wantWinners || len(nextUp) != tt.wantRunnersUp {
+				t.Errorf("Preview() = %d winners, %d runners-up, want %d and %d", len(winners), len(nextUp), tt.wantWinners, tt.wantRunnersUp)
+			}
+			for _, w := range nextUp {
+				if w.Rank <= DefaultPodiumSize {
+					t.Errorf("runner-up %d has podium rank %d", w.UserID, w.Rank)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package award
+
+import (
+	"fmt"
+	"sync"
+)
+
+// DefaultPodiumSize — количество призовых мест по умолчанию: золото, серебро и бронза.
+const DefaultPodiumSize = 3
+
+// Config — настройки расчета наград.
+type Config struct {
+	// TieBreaks переопределяет правило разрешения ничьих для наград по ключу.
+	TieBreaks map[string]TieBreak
+	// PodiumSize — сколько призовых мест сохраняется для каждой награды.
+	// Ноль означает DefaultPodiumSize.
+	PodiumSize int
+	// Estimators переопределяет способ оценки процента побед для наград по ключу.
+	Estimators map[string]Estimator
+	// WinrateMinGames — сколько игр за период нужно сыграть для участия в
+	// наградах за процент побед. nil означает DefaultWinrateMinGames, ноль —
+	// отсутствие порога.
+	WinrateMinGames *int
+}
+
+var (
+	cfgMu sync.RWMutex
+	cfg   Config
+)
+
+// Configure применяет настройки ко всем зарегистрированным наградам.
+func Configure(c Config) error {
+	if c.PodiumSize < 0 {
+		return fmt.Errorf("podium size must not be negative, got %d", c.PodiumSize)
+	}
+	for key := range c.TieBreaks {
+		if _, ok := Get(key); !ok {
+			return fmt.Errorf("tie-break configured for unknown award %q", key)
+		}
+	}
+	if c.WinrateMinGames != nil && *c.WinrateMinGames < 0 {
+		return fmt.Errorf("winrate minimum games must not be negative, got %d", *c.WinrateMinGames)
+	}
+	for key := range c.Estimators {
+		a, ok := Get(key)
+		if !ok {
+			return fmt.Errorf("winrate estimator configured for unknown award %q", key)
+		}
+		if _, ok := a.(winrateAward); !ok {
+			return fmt.Errorf("winrate estimator configured for award %q that is not a winrate award", key)
+		}
+	}
+
+	cfgMu.Lock()
+	defer cfgMu.Unlock()
+	cfg = c
+	return nil
+}
+
+// ParseTieBreaks разбирает правила разрешения ничьих, заданные по ключу награды.
+func ParseTieBreaks(raw map[string]string) (map[string]TieBreak, error) {
+	tieBreaks := make(map[string]TieBreak, len(raw))
+	for key, name := range raw {
+		t, err := ParseTieBreak(name)
+		if err != nil {
+			return nil, fmt.Errorf("award %s: %w", key, err)
+		}
+		tieBreaks[key] = t
+	}
+	return tieBreaks, nil
+}
+
+// ParseEstimators разбирает способы оценки процента побед, заданные по ключу награды.
+func ParseEstimators(raw map[string]string) (map[string]Estimator, error) {
+	estimators := make(map[string]Estimator, len(raw))
+	for key, name := range raw {
+		e, err := ParseEstimator(name)
+		if err != nil {
+			return nil, fmt.Errorf("award %s: %w", key, err)
+		}
+		estimators[key] = e
+	}
+	return estimators, nil
+}
+
+// tieBreakFor возвращает правило для награды с учетом настроек.
+func tieBreakFor(key string, def TieBreak) TieBreak {
+	cfgMu.RLock()
+	defer cfgMu.RUnlock()
+
+	if t, ok := cfg.TieBreaks[key]; ok {
+		return t
+	}
+	return def
+}
+
+// podiumSize возвращает количество призовых мест с учетом настроек.
+func podiumSize() int {
+	cfgMu.RLock()
+	defer cfgMu.RUnlock()
+
+	if cfg.PodiumSize > 0 {
+		return cfg.PodiumSize
+	}
+	return DefaultPodiumSize
+}
+
+// estimatorFor возвращает способ оценки процента побед для награды с учетом настроек.
+func estimatorFor(key string, def Estimator) Estimator {
+	cfgMu.RLock()
+	defer cfgMu.RUnlock()
+
+	if e, ok := cfg.Estimators[key]; ok {
+		return e
+	}
+	return def
+}
+
+// winrateMinGames возвращает порог по количеству игр для наград за процент побед.
+func winrateMinGames() int {
+	cfgMu.RLock()
+	defer cfgMu.RUnlock()
+
+	if cfg.WinrateMinGames != nil {
+		return *cfg.WinrateMinGames
+	}
+	return DefaultWinrateMinGames
+}
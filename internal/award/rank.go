@@ -0,0 +1,113 @@
+package award
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/lelouchhh/friendly-basketball-reward/internal/postgres"
+)
+
+// Order задает, какое значение показателя считается лучшим.
+type Order int
+
+const (
+	// Desc — лучше большее значение.
+	Desc Order = iota
+	// Asc — лучше меньшее значение.
+	Asc
+)
+
+// TieBreak — правило выбора победителя среди игроков с одинаковым показателем.
+type TieBreak int
+
+const (
+	// TieBreakShare — награду получают все игроки с одинаковым показателем.
+	TieBreakShare TieBreak = iota
+	// TieBreakEarliest — побеждает тот, кто достиг показателя раньше.
+	TieBreakEarliest
+	// TieBreakMostGames — побеждает тот, кто сыграл больше игр за период.
+	TieBreakMostGames
+)
+
+var tieBreakNames = map[TieBreak]string{
+	TieBreakShare:     "share",
+	TieBreakEarliest:  "earliest",
+	TieBreakMostGames: "most-games",
+}
+
+func (t TieBreak) String() string {
+	if name, ok := tieBreakNames[t]; ok {
+		return name
+	}
+	return fmt.Sprintf("TieBreak(%d)", int(t))
+}
+
+// ParseTieBreak разбирает правило по имени: share, earliest или most-games.
+func ParseTieBreak(name string) (TieBreak, error) {
+	for t, n := range tieBreakNames {
+		if n == name {
+			return t, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown tie-break policy %q", name)
+}
+
+// Ranked — кандидат с занятым местом.
+type Ranked struct {
+	postgres.Candidate
+	Rank int
+}
+
+// Rank распределяет места среди кандидатов отдельно для каждого типа награды
+// и возвращает тех, кто занял места с 1 по n. Игроки, которых правило tieBreak
+// не различает, делят одно место, а следующее место пропускается (1, 1, 3).
+func Rank(candidates []postgres.Candidate, order Order, tieBreak TieBreak, n int) []Ranked {
+	var types []string
+	groups := make(map[string][]postgres.Candidate)
+	for _, c := range candidates {
+		if _, ok := groups[c.RewardType]; !ok {
+			types = append(types, c.RewardType)
+		}
+		groups[c.RewardType] = append(groups[c.RewardType], c)
+	}
+
+	var ranked []Ranked
+	for _, t := range types {
+		group := groups[t]
+		better := func(a, b postgres.Candidate) bool {
+			return isBetter(a, b, order, tieBreak)
+		}
+		sort.SliceStable(group, func(i, j int) bool { return better(group[i], group[j]) })
+
+		rank := 0
+		for i, c := range group {
+			if i == 0 || better(group[i-1], c) {
+				rank = i + 1
+			}
+			if rank > n {
+				break
+			}
+			ranked = append(ranked, Ranked{Candidate: c, Rank: rank})
+		}
+	}
+	return ranked
+}
+
+// isBetter сообщает, стоит ли кандидат a строго выше кандидата b.
+func isBetter(a, b postgres.Candidate, order Order, tieBreak TieBreak) bool {
+	if a.Value != b.Value {
+		if order == Asc {
+			return a.Value < b.Value
+		}
+		return a.Value > b.Value
+	}
+
+	switch tieBreak {
+	case TieBreakEarliest:
+		return a.AchievedAt.Before(b.AchievedAt)
+	case TieBreakMostGames:
+		return a.Games > b.Games
+	default:
+		return false
+	}
+}
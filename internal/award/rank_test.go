@@ -0,0 +1,55 @@
+package award
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/lelouchhh/friendly-basketball-reward/internal/postgres"
+)
+
+func TestRank(t *testing.T) {
+	early := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+	late := time.Date(2025, 3, 20, 10, 0, 0, 0, time.UTC)
+	candidates := []postgres.Candidate{
+		{UserID: 1, RewardType: "a", Value: 10, AchievedAt: late, Games: 12},
+		{UserID: 2, RewardType: "a", Value: 10, AchievedAt: early, Games: 11},
+		{UserID: 3, RewardType: "a", Value: 7, AchievedAt: early, Games: 30},
+		{UserID: 4, RewardType: "b", Value: 3, AchievedAt: early, Games: 5},
+	}
+
+	type args struct {
+		order    Order
+		tieBreak TieBreak
+		n        int
+	}
+	tests := []struct {
+		name      string
+		args      args
+		wantUsers []int
+		wantRanks []int
+	}{
+		{name: "share ties", args: args{Desc, TieBreakShare, 1}, wantUsers: []int{1, 2, 4}, wantRanks: []int{1, 1, 1}},
+		{name: "earliest achiever", args: args{Desc, TieBreakEarliest, 1}, wantUsers: []int{2, 4}, wantRanks: []int{1, 1}},
+		{name: "most games", args: args{Desc, TieBreakMostGames, 1}, wantUsers: []int{1, 4}, wantRanks: []int{1, 1}},
+		{name: "ascending order", args: args{Asc, TieBreakShare, 1}, wantUsers: []int{3, 4}, wantRanks: []int{1, 1}},
+		{name: "shared rank skips next place", args: args{Desc, TieBreakShare, 3}, wantUsers: []int{1, 2, 3, 4}, wantRanks: []int{1, 1, 3, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Rank(append([]postgres.Candidate(nil), candidates...), tt.args.order, tt.args.tieBreak, tt.args.n)
+
+			var users, ranks []int
+			for _, r := range got {
+				users = append(users, r.UserID)
+				ranks = append(ranks, r.Rank)
+			}
+			if !reflect.DeepEqual(users, tt.wantUsers) {
+				t.Errorf("Rank() users = %v, want %v", users, tt.wantUsers)
+			}
+			if !reflect.DeepEqual(ranks, tt.wantRanks) {
+				t.Errorf("Rank() ranks = %v, want %v", ranks, tt.wantRanks)
+			}
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package award
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/lelouchhh/friendly-basketball-reward/internal/postgres"
+)
+
+// Estimator — способ оценки процента побед, по которому ранжируются игроки.
+type Estimator int
+
+const (
+	// EstimatorRaw — доля побед wins/games без поправки на размер выборки.
+	EstimatorRaw Estimator = iota
+	// EstimatorWilsonLower — нижняя граница доверительного интервала Уилсона:
+	// высокий процент побед на малом числе игр оценивается осторожно.
+	EstimatorWilsonLower
+	// EstimatorWilsonUpper — верхняя граница доверительного интервала Уилсона:
+	// низкий процент побед на малом числе игр оценивается осторожно.
+	EstimatorWilsonUpper
+	// EstimatorBayes — апостериорное среднее с априорным процентом побед,
+	// равным среднему по всем кандидатам награды, весом PriorGames игр.
+	EstimatorBayes
+)
+
+var estimatorNames = map[Estimator]string{
+	EstimatorRaw:         "raw",
+	EstimatorWilsonLower: "wilson_lower",
+	EstimatorWilsonUpper: "wilson_upper",
+	EstimatorBayes:       "bayes",
+}
+
+func (e Estimator) String() string {
+	if name, ok := estimatorNames[e]; ok {
+		return name
+	}
+	return fmt.Sprintf("Estimator(%d)", int(e))
+}
+
+// ParseEstimator разбирает способ оценки по имени: raw, wilson_lower, wilson_upper или bayes.
+func ParseEstimator(name string) (Estimator, error) {
+	for e, n := range estimatorNames {
+		if n == name {
+			return e, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown winrate estimator %q", name)
+}
+
+const (
+	// DefaultWinrateMinGames — сколько игр за период нужно сыграть по умолчанию,
+	// чтобы участвовать в наградах за процент побед.
+	DefaultWinrateMinGames = 10
+	// PriorGames — вес априорного процента побед для EstimatorBayes в играх.
+	PriorGames = 10
+	// wilsonZ — квантиль нормального распределения для 95% доверительного интервала.
+	wilsonZ = 1.96
+)
+
+// Estimate оценивает процент побед игрока с wins победами из games игр.
+// prior — априорный процент побед для EstimatorBayes.
+func (e Estimator) Estimate(wins, games int, prior float64) float64 {
+	if games <= 0 {
+		return 0
+	}
+	n := float64(games)
+	p := float64(wins) / n
+
+	switch e {
+	case EstimatorWilsonLower, EstimatorWilsonUpper:
+		z2 := wilsonZ * wilsonZ
+		center := p + z2/(2*n)
+		margin := wilsonZ * math.Sqrt(p*(1-p)/n+z2/(4*n*n))
+		if e == EstimatorWilsonLower {
+			margin = -margin
+		}
+		return (center + margin) / (1 + z2/n)
+	case EstimatorBayes:
+		return (float64(wins) + prior*PriorGames) / (n + PriorGames)
+	default:
+		return p
+	}
+}
+
+// winrateAward — награда за процент побед. Кандидаты, сыгравшие меньше
+// минимального количества игр, отбрасываются, а остальные ранжируются по
+// оценке процента побед. Значением награды остается фактическая доля побед,
+// а оценка, способ оценки и количество игр сохраняются вместе с ней.
+// По умолчанию лучший процент побед оценивается EstimatorWilsonLower, а
+// худший — EstimatorWilsonUpper, чтобы 10 побед из 10 игр не обгоняли 45 из 50.
+type winrateAward struct {
+	queryAward
+	estimator Estimator
+}
+
+func (a winrateAward) Compute(ctx context.Context, db *postgres.DB, period postgres.Period) ([]postgres.Winner, error) {
+	winners, _, err := a.Preview(ctx, db, period, 0)
+	return winners, err
+}
+
+// Preview возвращает призеров и до runnersUp следующих за ними мест.
+func (a winrateAward) Preview(ctx context.Context, db *postgres.DB, period postgres.Period, runnersUp int) (winners, nextUp []postgres.Winner, err error) {
+	candidates, err := a.query(db, ctx, period, a.rewardType)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	estimator := estimatorFor(a.key, a.estimator)
+	podium, rest := a.rank(scoreWinrates(candidates, estimator, winrateMinGames()), runnersUp)
+	for _, r := range podium {
+		winners = append(winners, a.winner(r, estimator))
+	}
+	for _, r := range rest {
+		nextUp = append(nextUp, a.winner(r, estimator))
+	}
+	return winners, nextUp, nil
+}
+
+// winner возвращает призера с фактической долей побед вместе с победами и
+// поражениями, оценкой, по которой он ранжирован, способом оценки и количеством игр.
+func (a winrateAward) winner(r Ranked, estimator Estimator) postgres.Winner {
+	wins, losses, score := r.Wins, r.Games-r.Wins, r.Value
+	w := a.queryAward.winner(r)
+	w.Value = float64(r.Wins) / float64(r.Games)
+	w.Details.Wins = &wins
+	w.Details.Losses = &losses
+	w.Details.Score = &score
+	w.Method = estimator.String()
+	w.SampleSize = r.Games
+	return w
+}
+
+// scoreWinrates оставляет кандидатов, сыгравших не меньше minGames игр, и
+// заменяет их показатель оценкой процента побед для ранжирования. Априорный процент побед для
+// EstimatorBayes считается отдельно для каждого типа награды.
+func scoreWinrates(candidates []postgres.Candidate, estimator Estimator, minGames int) []postgres.Candidate {
+	wins := make(map[string]int)
+	games := make(map[string]int)
+	for _, c := range candidates {
+		wins[c.RewardType] += c.Wins
+		games[c.RewardType] += c.Games
+	}
+
+	var scored []postgres.Candidate
+	for _, c := range candidates {
+		if c.Games < minGames {
+			continue
+		}
+		prior := float64(wins[c.RewardType]) / float64(games[c.RewardType])
+		c.Value = estimator.Estimate(c.Wins, c.Games, prior)
+		scored = append(scored, c)
+	}
+	return scored
+}
@@ -0,0 +1,136 @@
+package award
+
+import (
+	"math"
+	"testing"
+
+	"github.com/lelouchhh/friendly-basketball-reward/internal/postgres"
+)
+
+func TestEstimator_Estimate(t *testing.T) {
+	tests := []struct {
+		name      string
+		estimator Estimator
+		wins      int
+		games     int
+		prior     float64
+		want      float64
+	}{
+		{name: "raw", estimator: EstimatorRaw, wins: 45, games: 50, want: 0.9},
+		{name: "wilson lower, perfect record", estimator: EstimatorWilsonLower, wins: 10, games: 10, want: 0.7225},
+		{name: "wilson lower, large sample", estimator: EstimatorWilsonLower, wins: 45, games: 50, want: 0.7864},
+		{name: "wilson upper, no wins", estimator: EstimatorWilsonUpper, wins: 0, games: 10, want: 0.2775},
+		{name: "bayes", estimator: EstimatorBayes, wins: 10, games: 10, prior: 0.5, want: 0.75},
+		{name: "no games", estimator: EstimatorWilsonLower, wins: 0, games: 0, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.estimator.Estimate(tt.wins, tt.games, tt.prior); math.Abs(got-tt.want) > 1e-4 {
+				t.Errorf("Estimate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScoreWinrates(t *testing.T) {
+	candidates := []postgres.Candidate{
+		{UserID: 1, RewardType: "t", Value: 1, Wins: 10, Games: 10},
+		{UserID: 2, RewardType: "t", Value: 0.9, Wins: 45, Games: 50},
+		{UserID: 3, RewardType: "t", Value: 1, Wins: 3, Games: 3},
+		{UserID: 4, RewardType: "t", Value: 0.125, Wins: 5, Games: 40},
+	}
+
+	tests := []struct {
+		name      string
+		estimator Estimator
+		minGames  int
+		wantOrder []int
+	}{
+		{name: "raw prefers the perfect record", estimator: EstimatorRaw, minGames: 10, wantOrder: []int{1, 2, 4}},
+		{name: "wilson prefers the larger sample", estimator: EstimatorWilsonLower, minGames: 10, wantOrder: []int{2, 1, 4}},
+		{name: "bayes prefers the larger sample", estimator: EstimatorBayes, minGames: 10, wantOrder: []int{2, 1, 4}},
+		{name: "lower min games keeps small samples", estimator: EstimatorRaw, minGames: 1, wantOrder: []int{1, 3, 2, 4}},
+		{name: "zero min games keeps everyone", estimator: EstimatorRaw, minGames: 0, wantOrder: []int{1, 3, 2, 4}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []int
+			for _, r := range Rank(scoreWinrates(candidates, tt.estimator, tt.minGames), Desc, TieBreakMostGames, 10) {
+				got = append(got, r.UserID)
+			}
+			if len(got) != len(tt.wantOrder) {
+				t.Fatalf("ranked users = %v, want %v", got, tt.wantOrder)
+			}
+			for i := range got {
+				if got[i] != tt.wantOrder[i] {
+					t.Fatalf("ranked users = %v, want %v", got, tt.wantOrder)
+				}
+			}
+		})
+	}
+}
+
+func TestWinrateAward_Winner(t *testing.T) {
+	a := winrateAward{queryAward: queryAward{unit: postgres.UnitRatio}}
+	r := Ranked{Candidate: postgres.Candidate{UserID: 1, RewardType: "t", Wins: 10, Games: 10}, Rank: 1}
+	r.Value = EstimatorWilsonLower.Estimate(r.Wins, r.Games, 0)
+
+	w := a.winner(r, EstimatorWilsonLower)
+	if w.Value != 1 {
+		t.Errorf("winner() value = %v, want the actual win rate 1", w.Value)
+	}
+	if w.Details.Score == nil || math.Abs(*w.Details.Score-0.7225) > 1e-4 {
+		t.Errorf("winner() score = %v, want 0.7225", w.Details.Score)
+	}
+	if w.Method != "wilson_lower" || w.SampleSize != 10 {
+		t.Errorf("winner() method = %q, sample size = %d, want wilson_lower and 10", w.Method, w.SampleSize)
+	}
+}
+
+// TestWinrateDefaults проверяет, что без настроек награды за процент побед
+// ранжируются по границам Уилсона, а не по фактической доле побед.
+func TestWinrateDefaults(t *testing.T) {
+	tests := []struct {
+		key  string
+		want Estimator
+	}{
+		{"top-winrate", EstimatorWilsonLower},
+		{"bottom-winrate", EstimatorWilsonUpper},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			a, ok := Get(tt.key)
+			if !ok {
+				t.Fatalf("award %q is not registered", tt.key)
+			}
+			if got := estimatorFor(tt.key, a.(winrateAward).estimator); got != tt.want {
+				t.Errorf("estimator = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWinrateMinGames(t *testing.T) {
+	zero, five := 0, 5
+	tests := []struct {
+		name     string
+		minGames *int
+		want     int
+	}{
+		{"unset", nil, DefaultWinrateMinGames},
+		{"zero disables the minimum", &zero, 0},
+		{"configured", &five, 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Configure(Config{WinrateMinGames: tt.minGames}); err != nil {
+				t.Fatalf("Configure() error = %v", err)
+			}
+			t.Cleanup(func() { _ = Configure(Config{}) })
+
+			if got := winrateMinGames(); got != tt.want {
+				t.Errorf("winrateMinGames() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,16 +1,100 @@
 package config
 
-import "os"
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
 
 type Config struct {
 	PostgresConn string
 	CronSpec     string
+	// Schedules — cron-выражения для наград за другие периоды,
+	// например CRON_SCHEDULES=week=0 0 * * 1;quarter=0 0 1 1,4,7,10 *.
+	// По расписанию считаются только week, quarter и year: сезоны задаются явно.
+	Schedules map[string]string
+	// TieBreaks — правила разрешения ничьих по ключу награды,
+	// например AWARD_TIE_BREAKS=top-rating=earliest,max-games-played=most-games.
+	TieBreaks map[string]string
+	// PodiumSize — количество призовых мест для каждой награды.
+	PodiumSize int
+	// WinrateEstimators — способы оценки процента побед по ключу награды,
+	// например AWARD_WINRATE_ESTIMATORS=top-winrate=wilson_lower,bottom-winrate=bayes.
+	// По умолчанию top-winrate использует wilson_lower, а bottom-winrate — wilson_upper.
+	WinrateEstimators map[string]string
+	// WinrateMinGames — минимальное количество игр за период для наград за процент побед.
+	// nil, если WINRATE_MIN_GAMES не задан: тогда действует порог по умолчанию. Ноль отключает порог.
+	WinrateMinGames *int
+	// HTTPAddr — адрес HTTP API, например ":8080".
+	HTTPAddr string
+	// AdminToken — токен для административных методов HTTP API.
+	AdminToken string
+	// ShutdownTimeout — сколько ждать завершения текущих расчетов при остановке.
+	ShutdownTimeout time.Duration
+	// CatchUpPeriods — за сколько прошедших периодов при старте досчитываются
+	// пропущенные награды. Ноль отключает досчет.
+	CatchUpPeriods int
+	// Timezone — часовой пояс лиги в формате IANA, например Europe/Moscow.
+	// Local не поддерживается: часовой пояс передается в сессию Postgres.
+	// По нему считаются границы периодов и расписание cron.
+	Timezone string
 }
 
 func NewConfig() Config {
 
 	return Config{
-		PostgresConn: os.Getenv("POSTGRES_CONNECTION"),
-		CronSpec:     os.Getenv("CRON_SCHEDULE"),
+		PostgresConn:      os.Getenv("POSTGRES_CONNECTION"),
+		CronSpec:          os.Getenv("CRON_SCHEDULE"),
+		Schedules:         parsePairs(os.Getenv("CRON_SCHEDULES"), ";"),
+		TieBreaks:         parsePairs(os.Getenv("AWARD_TIE_BREAKS"), ","),
+		PodiumSize:        parseInt(os.Getenv("PODIUM_SIZE"), 0),
+		WinrateEstimators: parsePairs(os.Getenv("AWARD_WINRATE_ESTIMATORS"), ","),
+		WinrateMinGames:   parseOptionalInt(os.Getenv("WINRATE_MIN_GAMES")),
+		HTTPAddr:          os.Getenv("HTTP_ADDR"),
+		AdminToken:        os.Getenv("ADMIN_TOKEN"),
+		ShutdownTimeout:   parseDuration(os.Getenv("SHUTDOWN_TIMEOUT"), 30*time.Second),
+		CatchUpPeriods:    parseInt(os.Getenv("CATCHUP_PERIODS"), 3),
+		Timezone:          os.Getenv("LEAGUE_TIMEZONE"),
 	}
 }
+
+// parsePairs разбирает строку вида "key=value<sep>key=value".
+func parsePairs(raw string, sep string) map[string]string {
+	pairs := make(map[string]string)
+	for _, item := range strings.Split(raw, sep) {
+		key, value, ok := strings.Cut(strings.TrimSpace(item), "=")
+		if !ok {
+			continue
+		}
+		pairs[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	return pairs
+}
+
+// parseInt разбирает целое число, возвращая def для пустой или некорректной строки.
+func parseInt(raw string, def int) int {
+	n, err := strconv.Atoi(strings.TrimSpace(raw))
+	if err != nil {
+		return def
+	}
+	return n
+}
+
+// parseOptionalInt разбирает целое число, возвращая nil для пустой или некорректной строки.
+func parseOptionalInt(raw string) *int {
+	n, err := strconv.Atoi(strings.TrimSpace(raw))
+	if err != nil {
+		return nil
+	}
+	return &n
+}
+
+// parseDuration разбирает длительность вида "30s", возвращая def для пустой или некорректной строки.
+func parseDuration(raw string, def time.Duration) time.Duration {
+	d, err := time.ParseDuration(strings.TrimSpace(raw))
+	if err != nil || d <= 0 {
+		return def
+	}
+	return d
+}
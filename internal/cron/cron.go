@@ -2,130 +2,117 @@ package cron
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"time"
 
+	"github.com/lelouchhh/friendly-basketball-reward/internal/award"
 	"github.com/lelouchhh/friendly-basketball-reward/internal/postgres"
 	"github.com/robfig/cron/v3"
 )
 
-// StartCronJobs запускает cron-задачи для расчета наград за предыдущий месяц.
-func StartCronJobs(db *postgres.DB, input string) {
-	c := cron.New()
+// Scheduler — запущенные cron-задачи расчета наград.
+type Scheduler struct {
+	c      *cron.Cron
+	cancel context.CancelFunc
+}
 
-	// Используем cron-выражение для первого дня каждого месяца
-	_, err := c.AddFunc(input, func() {
-		log.Println("Running monthly cron job...")
+// StartCronJobs запускает cron-задачи для расчета наград. Для каждой длительности
+// периода задается своё cron-выражение, а задача считает награды за только что
+// закончившийся период этой длительности. Расчеты выполняются в контексте,
+// производном от ctx, и прерываются при его отмене. Расписание и границы
+// периодов вычисляются в часовом поясе лиги loc.
+func StartCronJobs(ctx context.Context, db *postgres.DB, schedules map[postgres.Granularity]string, loc *time.Location) (*Scheduler, error) {
+	jobCtx, cancel := context.WithCancel(ctx)
+	c := cron.New(cron.WithLocation(loc))
 
-		// Определяем предыдущий месяц
-		now := time.Now()
-		prevMonth := now.AddDate(0, -1, 0)
-		year := prevMonth.Format("2006") // Год предыдущего месяца
-		month := prevMonth.Format("01")  // Месяц предыдущего месяца
+	for granularity, spec := range schedules {
+		_, err := c.AddFunc(spec, func() {
+			log.Printf("Running %s cron job...", granularity)
 
-		// Создаем контекст
-		ctx := context.Background()
+			// Определяем предыдущий период
+			current, err := postgres.NewPeriod(granularity, time.Now().In(loc))
+			if err != nil {
+				log.Printf("Failed to determine %s period: %v", granularity, err)
+				return
+			}
+			period := current.Previous()
 
-		// Вычисляем и сохраняем награды
-		go processTopRatingPerMonth(ctx, db, year, month)
-		go processWorstRatingPerMonth(ctx, db, year, month)
-		go processTopWinratePerMonth(ctx, db, year, month)
-		go processBottomWinratePerMonth(ctx, db, year, month)
-		go processTopGainedRatingMonth(ctx, db, year, month)
-		go processTopLostRatingMonth(ctx, db, year, month)
-		go processMaxGamesPlayed(ctx, db, year, month)
-		go processLongestWinner(ctx, db, year, month)
-	})
-	if err != nil {
-		log.Fatalf("Failed to schedule cron job: %v", err)
+			// Вычисляем и сохраняем все награды одной транзакцией
+			if _, err := award.ComputePeriod(jobCtx, db, period, award.Options{}); err != nil {
+				log.Printf("%s cron job failed for %s: %v", granularity, period, err)
+			}
+		})
+		if err != nil {
+			cancel()
+			return nil, fmt.Errorf("failed to schedule %s cron job: %w", granularity, err)
+		}
 	}
 
 	c.Start()
 	log.Println("Cron jobs started successfully")
+	return &Scheduler{c: c, cancel: cancel}, nil
 }
 
-// processTopRatingPerMonth вычисляет лучшего игрока месяца и сохраняет награду.
-func processTopRatingPerMonth(ctx context.Context, db *postgres.DB, year, month string) {
-	log.Printf("Processing top rating for %s-%s...", year, month)
-	err := db.TopRatingPerMonth(ctx, year, month)
-	if err != nil {
-		log.Printf("Failed to process top rating for %s-%s: %v", year, month, err)
-	} else {
-		log.Printf("Successfully processed top rating for %s-%s", year, month)
-	}
-}
+// Stop перестает запускать новые задачи и ждет завершения текущих. Если они не
+// успели завершиться до отмены ctx, их контекст отменяется, незавершенные
+// транзакции откатываются, а Stop возвращает ошибку ctx.
+func (s *Scheduler) Stop(ctx context.Context) error {
+	defer s.cancel()
 
-// processWorstRatingPerMonth вычисляет худшего игрока месяца и сохраняет награду.
-func processWorstRatingPerMonth(ctx context.Context, db *postgres.DB, year, month string) {
-	log.Printf("Processing worst rating for %s-%s...", year, month)
-	err := db.WorstRatingPerMonth(ctx, year, month)
-	if err != nil {
-		log.Printf("Failed to process worst rating for %s-%s: %v", year, month, err)
-	} else {
-		log.Printf("Successfully processed worst rating for %s-%s", year, month)
+	done := s.c.Stop()
+	select {
+	case <-done.Done():
+		log.Println("Cron jobs stopped")
+		return nil
+	case <-ctx.Done():
+		log.Println("Cron jobs did not finish in time, cancelling")
+		s.cancel()
+		<-done.Done()
+		return ctx.Err()
 	}
 }
 
-// processTopWinratePerMonth вычисляет лучший процент побед и сохраняет награду.
-func processTopWinratePerMonth(ctx context.Context, db *postgres.DB, year, month string) {
-	log.Printf("Processing top winrate for %s-%s...", year, month)
-	err := db.TopWinratePerMonth(ctx, year, month)
-	if err != nil {
-		log.Printf("Failed to process top winrate for %s-%s: %v", year, month, err)
-	} else {
-		log.Printf("Successfully processed top winrate for %s-%s", year, month)
-	}
-}
+// CatchUp досчитывает награды за последние lookBack закончившихся периодов
+// каждой длительности, если они не были посчитаны полностью, например потому,
+// что сервис не работал в момент запуска задачи. Периоды обрабатываются от
+// старых к новым. При отмене ctx досчет прекращается, а расчет текущего
+// периода откатывается.
+func CatchUp(ctx context.Context, db *postgres.DB, granularities []postgres.Granularity, lookBack int, loc *time.Location) {
+	for _, granularity := range granularities {
+		current, err := postgres.NewPeriod(granularity, time.Now().In(loc))
+		if err != nil {
+			log.Printf("Skipping %s catch-up: %v", granularity, err)
+			continue
+		}
 
-// processBottomWinratePerMonth вычисляет худший процент побед и сохраняет награду.
-func processBottomWinratePerMonth(ctx context.Context, db *postgres.DB, year, month string) {
-	log.Printf("Processing bottom winrate for %s-%s...", year, month)
-	err := db.BottomWinratePerMonth(ctx, year, month)
-	if err != nil {
-		log.Printf("Failed to process bottom winrate for %s-%s: %v", year, month, err)
-	} else {
-		log.Printf("Successfully processed bottom winrate for %s-%s", year, month)
-	}
-}
+		periods := make([]postgres.Period, lookBack)
+		for i, period := lookBack-1, current.Previous(); i >= 0; i, period = i-1, period.Previous() {
+			periods[i] = period
+		}
 
-// processTopGainedRatingMonth вычисляет максимальный прирост рейтинга и сохраняет награду.
-func processTopGainedRatingMonth(ctx context.Context, db *postgres.DB, year, month string) {
-	log.Printf("Processing top gained rating for %s-%s...", year, month)
-	err := db.TopGainedRatingMonth(ctx, year, month)
-	if err != nil {
-		log.Printf("Failed to process top gained rating for %s-%s: %v", year, month, err)
-	} else {
-		log.Printf("Successfully processed top gained rating for %s-%s", year, month)
-	}
-}
+		for _, period := range periods {
+			if ctx.Err() != nil {
+				return
+			}
 
-// processTopLostRatingMonth вычисляет максимальную потерю рейтинга и сохраняет награду.
-func processTopLostRatingMonth(ctx context.Context, db *postgres.DB, year, month string) {
-	log.Printf("Processing top lost rating for %s-%s...", year, month)
-	err := db.TopLostRatingMonth(ctx, year, month)
-	if err != nil {
-		log.Printf("Failed to process top lost rating for %s-%s: %v", year, month, err)
-	} else {
-		log.Printf("Successfully processed top lost rating for %s-%s", year, month)
-	}
-}
+			pending, err := award.Pending(ctx, db, period, award.All())
+			if err != nil {
+				log.Printf("Failed to check %s for missed awards: %v", period, err)
+				continue
+			}
+			if len(pending) == 0 {
+				continue
+			}
 
-// processMaxGamesPlayed вычисляет наибольшее количество сыгранных игр и сохраняет награду.
-func processMaxGamesPlayed(ctx context.Context, db *postgres.DB, year, month string) {
-	log.Printf("Processing max games played for %s-%s...", year, month)
-	err := db.MaxGamesPlayed(ctx, year, month)
-	if err != nil {
-		log.Printf("Failed to process max games played for %s-%s: %v", year, month, err)
-	} else {
-		log.Printf("Successfully processed max games played for %s-%s", year, month)
-	}
-}
-func processLongestWinner(ctx context.Context, db *postgres.DB, year, month string) {
-	log.Printf("Processing logest winner for %s-%s...", year, month)
-	err := db.LongestWinStreak(ctx, year, month)
-	if err != nil {
-		log.Printf("Failed to process logest winner for %s-%s: %v", year, month, err)
-	} else {
-		log.Printf("Successfully processed logest winner for %s-%s", year, month)
+			keys := make([]string, 0, len(pending))
+			for _, a := range pending {
+				keys = append(keys, a.Key())
+			}
+			log.Printf("Catching up %d missed awards for %s", len(keys), period)
+			if _, err := award.ComputePeriod(ctx, db, period, award.Options{Awards: keys}); err != nil {
+				log.Printf("Catch-up failed for %s: %v", period, err)
+			}
+		}
 	}
 }
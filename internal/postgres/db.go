@@ -2,16 +2,44 @@ package postgres
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"log"
+	"time"
 )
 
+// Querier — общий набор методов пула соединений и транзакции.
+type Querier interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults
+}
+
 type DB struct {
 	Conn *pgxpool.Pool
+	tx   pgx.Tx
 }
 
-func NewDB(url string) (*DB, error) {
-	pool, err := pgxpool.New(context.Background(), url)
+// NewDB подключается к базе. Часовой пояс сессии устанавливается в loc,
+// чтобы границы периодов в SQL совпадали с границами, вычисленными в Go.
+// loc должен иметь имя IANA: Postgres не знает часового пояса Local.
+func NewDB(url string, loc *time.Location) (*DB, error) {
+	if loc.String() == "Local" {
+		return nil, errors.New("league timezone must be an IANA name such as Europe/Moscow, not Local")
+	}
+
+	cfg, err := pgxpool.ParseConfig(url)
+	if err != nil {
+		log.Println("Can't parse db config", err)
+		return nil, err
+	}
+	cfg.ConnConfig.RuntimeParams["timezone"] = loc.String()
+
+	pool, err := pgxpool.NewWithConfig(context.Background(), cfg)
 	if err != nil {
 		log.Println("Can't connect to db", err)
 		return nil, err
@@ -23,3 +51,58 @@ func (db *DB) Close() {
 	db.Conn.Close()
 	log.Println("Database connection closed")
 }
+
+// querier возвращает текущую транзакцию, если она открыта, иначе пул соединений.
+func (db *DB) querier() Querier {
+	if db.tx != nil {
+		return db.tx
+	}
+	return db.Conn
+}
+
+// InTx выполняет fn в транзакции и фиксирует её, если fn не вернула ошибку.
+// Внутри уже открытой транзакции создается точка сохранения.
+func (db *DB) InTx(ctx context.Context, fn func(tx *DB) error) (err error) {
+	var tx pgx.Tx
+	if db.tx != nil {
+		tx, err = db.tx.Begin(ctx)
+	} else {
+		tx, err = db.Conn.Begin(ctx)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(ctx); rbErr != nil && !errors.Is(rbErr, pgx.ErrTxClosed) {
+				log.Printf("Failed to rollback transaction: %v", rbErr)
+			}
+		}
+	}()
+
+	if err = fn(&DB{Conn: db.Conn, tx: tx}); err != nil {
+		return err
+	}
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// errRollback прерывает транзакцию InRollbackTx после успешного выполнения fn.
+var errRollback = errors.New("transaction rolled back")
+
+// InRollbackTx выполняет fn в транзакции, как InTx, но всегда откатывает её:
+// изменения fn видны только внутри fn. Так выполняется предпросмотр.
+func (db *DB) InRollbackTx(ctx context.Context, fn func(tx *DB) error) error {
+	err := db.InTx(ctx, func(tx *DB) error {
+		if err := fn(tx); err != nil {
+			return err
+		}
+		return errRollback
+	})
+	if errors.Is(err, errRollback) {
+		return nil
+	}
+	return err
+}
@@ -1,5 +1,7 @@
 package postgres
 
+import "time"
+
 type Rating struct {
 	UserID    int
 	FirstName string
@@ -8,3 +10,129 @@ type Rating struct {
 	Icon      string
 	MaxRating float64
 }
+
+// Candidate — показатель игрока за период, по которому выбираются победители.
+// RewardType — код типа награды.
+type Candidate struct {
+	UserID     int
+	RewardType string
+	Value      float64
+	// AchievedAt — момент, когда игрок достиг показателя.
+	AchievedAt time.Time
+	// StartedAt — начало серии, заполняется только для наград за серии игр.
+	StartedAt time.Time
+	// Games — количество игр, сыгранных игроком за период.
+	Games int
+	// Wins — количество побед за период, заполняется только для наград за процент побед.
+	Wins int
+	// GameType — формат игры, по которому посчитан показатель. Пустой для всех игр.
+	GameType string
+	// GameIDs — игры, подтверждающие показатель, например игры серии побед.
+	GameIDs []int
+}
+
+// Единицы измерения значения награды.
+const (
+	UnitRating       = "rating"
+	UnitRatingPoints = "rating_points"
+	UnitRatio        = "ratio"
+	UnitGames        = "games"
+	UnitWins         = "wins"
+	UnitLosses       = "losses"
+)
+
+// RewardDetails — подробности значения награды, хранятся в statistic.reward.details.
+// Wins и Losses заданы только для наград, которые их учитывают, Score — только
+// для наград за процент побед, а StartedAt и EndedAt — только для наград за серии игр.
+type RewardDetails struct {
+	Games    int    `json:"games,omitempty"`
+	Wins     *int   `json:"wins,omitempty"`
+	Losses   *int   `json:"losses,omitempty"`
+	GameType string `json:"game_type,omitempty"`
+	// Score — оценка процента побед способом Winner.Method, по которой ранжированы игроки.
+	Score     *float64   `json:"score,omitempty"`
+	StartedAt *time.Time `json:"started_at,omitempty"`
+	EndedAt   *time.Time `json:"ended_at,omitempty"`
+}
+
+// Winner — призер награды за период. Rank — занятое место, начиная с 1.
+type Winner struct {
+	UserID     int     `json:"user_id"`
+	RewardType string  `json:"reward_type"`
+	Rank       int     `json:"rank"`
+	Value      float64 `json:"value"`
+	// Unit — единица измерения Value, например UnitRating.
+	Unit    string        `json:"unit"`
+	Details RewardDetails `json:"details"`
+	// Method — способ оценки значения, например "wilson_lower" для процента побед.
+	Method string `json:"method,omitempty"`
+	// SampleSize — количество игр, по которым посчитано значение.
+	SampleSize int `json:"sample_size,omitempty"`
+	// Evidence — идентификаторы игр, подтверждающих награду.
+	Evidence []int `json:"evidence,omitempty"`
+	// Title — название награды для вывода, заполняется только при показе.
+	Title string `json:"title,omitempty"`
+}
+
+// Reward — сохраненная награда вместе с данными игрока.
+type Reward struct {
+	ID          int           `json:"id"`
+	UserID      int           `json:"user_id"`
+	FirstName   string        `json:"first_name"`
+	LastName    string        `json:"last_name"`
+	Number      string        `json:"number"`
+	Icon        string        `json:"icon"`
+	TypeID      int           `json:"type_id"`
+	TypeCode    string        `json:"type_code"`
+	Type        string        `json:"type"`
+	Description string        `json:"description"`
+	Rank        int           `json:"rank"`
+	Value       float64       `json:"value"`
+	Unit        string        `json:"unit"`
+	Details     RewardDetails `json:"details"`
+	Evidence    []int         `json:"evidence"`
+	Method      string        `json:"method,omitempty"`
+	SampleSize  int           `json:"sample_size,omitempty"`
+	PeriodStart time.Time     `json:"period_start"`
+	PeriodEnd   time.Time     `json:"period_end"`
+	Granularity string        `json:"granularity"`
+	CreatedAt   time.Time     `json:"created_at"`
+}
+
+// RewardType — тип награды из statistic.reward_type. Code — стабильный код
+// типа, Type и Description — его название и описание на выбранном языке.
+type RewardType struct {
+	ID          int    `json:"id"`
+	Code        string `json:"code"`
+	Type        string `json:"type"`
+	Description string `json:"description"`
+}
+
+// StreakRecord — личный рекорд серии игрока за всё время вместе с данными игрока.
+// GameType пустой для серий по всем играм.
+type StreakRecord struct {
+	UserID    int       `json:"user_id"`
+	FirstName string    `json:"first_name"`
+	LastName  string    `json:"last_name"`
+	Number    string    `json:"number"`
+	Icon      string    `json:"icon"`
+	Kind      string    `json:"kind"`
+	GameType  string    `json:"game_type"`
+	Length    int       `json:"length"`
+	StartedAt time.Time `json:"started_at"`
+	EndedAt   time.Time `json:"ended_at"`
+	GameIDs   []int     `json:"game_ids"`
+}
+
+// LeaderboardEntry — количество медалей игрока.
+type LeaderboardEntry struct {
+	UserID    int    `json:"user_id"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	Number    string `json:"number"`
+	Icon      string `json:"icon"`
+	Gold      int    `json:"gold"`
+	Silver    int    `json:"silver"`
+	Bronze    int    `json:"bronze"`
+	Total     int    `json:"total"`
+}
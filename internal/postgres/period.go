@@ -0,0 +1,223 @@
+package postgres
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Granularity — длительность периода, за который считаются награды.
+type Granularity string
+
+const (
+	GranularityWeek    Granularity = "week"
+	GranularityMonth   Granularity = "month"
+	GranularityQuarter Granularity = "quarter"
+	GranularityYear    Granularity = "year"
+	// GranularitySeason — произвольный сезон с заданными границами.
+	GranularitySeason Granularity = "season"
+)
+
+// ParseGranularity разбирает длительность периода по имени.
+func ParseGranularity(name string) (Granularity, error) {
+	switch g := Granularity(name); g {
+	case GranularityWeek, GranularityMonth, GranularityQuarter, GranularityYear, GranularitySeason:
+		return g, nil
+	}
+	return "", fmt.Errorf("unknown period granularity %q", name)
+}
+
+// Calendar сообщает, есть ли у периодов длительности g календарные границы.
+// Только такие периоды NewPeriod может вычислить по моменту времени, поэтому
+// только их можно считать по расписанию.
+func (g Granularity) Calendar() bool {
+	switch g {
+	case GranularityWeek, GranularityMonth, GranularityQuarter, GranularityYear:
+		return true
+	}
+	return false
+}
+
+// Period — полуоткрытый интервал дат [Start, End), за который считаются награды.
+type Period struct {
+	Start       time.Time
+	End         time.Time
+	Granularity Granularity
+}
+
+const dateLayout = "2006-01-02"
+
+// NewPeriod возвращает период длительности g, содержащий момент t.
+// Границы периода вычисляются в часовом поясе t.
+func NewPeriod(g Granularity, t time.Time) (Period, error) {
+	y, m, d := t.Date()
+	var start time.Time
+	switch g {
+	case GranularityWeek:
+		// Неделя начинается с понедельника
+		offset := (int(t.Weekday()) + 6) % 7
+		start = time.Date(y, m, d-offset, 0, 0, 0, 0, t.Location())
+	case GranularityMonth:
+		start = time.Date(y, m, 1, 0, 0, 0, 0, t.Location())
+	case GranularityQuarter:
+		start = time.Date(y, (m-1)/3*3+1, 1, 0, 0, 0, 0, t.Location())
+	case GranularityYear:
+		start = time.Date(y, time.January, 1, 0, 0, 0, 0, t.Location())
+	default:
+		return Period{}, fmt.Errorf("period granularity %q has no calendar boundaries", g)
+	}
+	return Period{Start: start, End: advance(g, start, 1), Granularity: g}, nil
+}
+
+// MonthPeriod возвращает период за календарный месяц.
+func MonthPeriod(year int, month time.Month) Period {
+	p, _ := NewPeriod(GranularityMonth, time.Date(year, month, 1, 0, 0, 0, 0, time.UTC))
+	return p
+}
+
+// SeasonPeriod возвращает сезон с произвольными границами [start, end).
+func SeasonPeriod(start, end time.Time) (Period, error) {
+	if !start.Before(end) {
+		return Period{}, fmt.Errorf("season start %s must be before end %s", start.Format(dateLayout), end.Format(dateLayout))
+	}
+	return Period{Start: start, End: end, Granularity: GranularitySeason}, nil
+}
+
+// Previous возвращает предыдущий период той же длительности.
+func (p Period) Previous() Period {
+	if p.Granularity == GranularitySeason {
+		days := p.days()
+		return Period{Start: p.Start.AddDate(0, 0, -days), End: p.Start, Granularity: p.Granularity}
+	}
+	return Period{Start: advance(p.Granularity, p.Start, -1), End: p.Start, Granularity: p.Granularity}
+}
+
+// Next возвращает следующий период той же длительности.
+func (p Period) Next() Period {
+	if p.Granularity == GranularitySeason {
+		days := p.days()
+		return Period{Start: p.End, End: p.End.AddDate(0, 0, days), Granularity: p.Granularity}
+	}
+	return Period{Start: p.End, End: advance(p.Granularity, p.End, 1), Granularity: p.Granularity}
+}
+
+// PeriodRange возвращает периоды от from до to включительно, шагая Next.
+// Сезоны идут подряд с длительностью from, поэтому если шаги перешагивают
+// начало to, возвращается ошибка: иначе часть сезонов была бы посчитана с
+// чужими границами.
+func PeriodRange(from, to Period) ([]Period, error) {
+	var periods []Period
+	for period := from; !period.Start.After(to.Start); period = period.Next() {
+		periods = append(periods, period)
+	}
+	if len(periods) == 0 {
+		return nil, fmt.Errorf("period %s is before %s", to, from)
+	}
+	if last := periods[len(periods)-1]; !last.Start.Equal(to.Start) || !last.End.Equal(to.End) {
+		return nil, fmt.Errorf("period %s is not reached from %s in steps of %s", to, from, from.Granularity)
+	}
+	return periods, nil
+}
+
+// StartDate возвращает первый день периода в формате YYYY-MM-DD.
+func (p Period) StartDate() string {
+	return p.Start.Format(dateLayout)
+}
+
+// EndDate возвращает первый день после периода в формате YYYY-MM-DD.
+func (p Period) EndDate() string {
+	return p.End.Format(dateLayout)
+}
+
+// String возвращает период в том же виде, который принимает ParsePeriod:
+// 2025-W10, 2025-03, 2025-Q1, 2025 или 2024-09-01..2025-05-31 для сезона.
+func (p Period) String() string {
+	switch p.Granularity {
+	case GranularityWeek:
+		y, w := p.Start.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", y, w)
+	case GranularityMonth:
+		return p.Start.Format("2006-01")
+	case GranularityQuarter:
+		return fmt.Sprintf("%d-Q%d", p.Start.Year(), (int(p.Start.Month())-1)/3+1)
+	case GranularityYear:
+		return strconv.Itoa(p.Start.Year())
+	default:
+		// Для сезона конец выводится включительно
+		return p.StartDate() + ".." + p.End.AddDate(0, 0, -1).Format(dateLayout)
+	}
+}
+
+var (
+	weekPattern    = regexp.MustCompile(`^(\d{4})-W(\d{2})$`)
+	quarterPattern = regexp.MustCompile(`^(\d{4})-Q([1-4])$`)
+	yearPattern    = regexp.MustCompile(`^\d{4}$`)
+)
+
+// ParsePeriod разбирает период в формате, который возвращает Period.String.
+// Даты без часового пояса интерпретируются в loc.
+func ParsePeriod(s string, loc *time.Location) (Period, error) {
+	s = strings.TrimSpace(s)
+
+	if from, to, ok := strings.Cut(s, ".."); ok {
+		start, err := time.ParseInLocation(dateLayout, from, loc)
+		if err != nil {
+			return Period{}, fmt.Errorf("invalid season start: %w", err)
+		}
+		last, err := time.ParseInLocation(dateLayout, to, loc)
+		if err != nil {
+			return Period{}, fmt.Errorf("invalid season end: %w", err)
+		}
+		return SeasonPeriod(start, last.AddDate(0, 0, 1))
+	}
+
+	if m := weekPattern.FindStringSubmatch(s); m != nil {
+		year, _ := strconv.Atoi(m[1])
+		week, _ := strconv.Atoi(m[2])
+		// 4 января всегда попадает в первую неделю ISO
+		jan4 := time.Date(year, time.January, 4, 0, 0, 0, 0, loc)
+		p, _ := NewPeriod(GranularityWeek, jan4.AddDate(0, 0, (week-1)*7))
+		if y, w := p.Start.ISOWeek(); y != year || w != week {
+			return Period{}, fmt.Errorf("invalid week %q", s)
+		}
+		return p, nil
+	}
+
+	if m := quarterPattern.FindStringSubmatch(s); m != nil {
+		year, _ := strconv.Atoi(m[1])
+		quarter, _ := strconv.Atoi(m[2])
+		return NewPeriod(GranularityQuarter, time.Date(year, time.Month((quarter-1)*3+1), 1, 0, 0, 0, 0, loc))
+	}
+
+	if yearPattern.MatchString(s) {
+		year, _ := strconv.Atoi(s)
+		return NewPeriod(GranularityYear, time.Date(year, time.January, 1, 0, 0, 0, 0, loc))
+	}
+
+	month, err := time.ParseInLocation("2006-01", s, loc)
+	if err != nil {
+		return Period{}, fmt.Errorf("invalid period %q", s)
+	}
+	return NewPeriod(GranularityMonth, month)
+}
+
+// days возвращает длительность периода в днях.
+func (p Period) days() int {
+	return int(p.End.Sub(p.Start).Round(24*time.Hour) / (24 * time.Hour))
+}
+
+// advance сдвигает начало периода на n периодов длительности g.
+func advance(g Granularity, t time.Time, n int) time.Time {
+	switch g {
+	case GranularityWeek:
+		return t.AddDate(0, 0, 7*n)
+	case GranularityQuarter:
+		return t.AddDate(0, 3*n, 0)
+	case GranularityYear:
+		return t.AddDate(n, 0, 0)
+	default:
+		return t.AddDate(0, n, 0)
+	}
+}
@@ -0,0 +1,138 @@
+package postgres
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParsePeriod(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		wantStart string
+		wantEnd   string
+		wantGran  Granularity
+		wantErr   bool
+	}{
+		{name: "month", input: "2025-03", wantStart: "2025-03-01", wantEnd: "2025-04-01", wantGran: GranularityMonth},
+		{name: "week", input: "2025-W01", wantStart: "2024-12-30", wantEnd: "2025-01-06", wantGran: GranularityWeek},
+		{name: "quarter", input: "2025-Q4", wantStart: "2025-10-01", wantEnd: "2026-01-01", wantGran: GranularityQuarter},
+		{name: "year", input: "2024", wantStart: "2024-01-01", wantEnd: "2025-01-01", wantGran: GranularityYear},
+		{name: "season", input: "2024-09-01..2025-05-31", wantStart: "2024-09-01", wantEnd: "2025-06-01", wantGran: GranularitySeason},
+		{name: "invalid week", input: "2025-W60", wantErr: true},
+		{name: "reversed season", input: "2025-05-31..2024-09-01", wantErr: true},
+		{name: "garbage", input: "march", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParsePeriod(tt.input, time.UTC)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParsePeriod() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got.StartDate() != tt.wantStart || got.EndDate() != tt.wantEnd || got.Granularity != tt.wantGran {
+				t.Errorf("ParsePeriod() = [%s, %s) %s, want [%s, %s) %s",
+					got.StartDate(), got.EndDate(), got.Granularity, tt.wantStart, tt.wantEnd, tt.wantGran)
+			}
+			if got.String() != tt.input {
+				t.Errorf("String() = %v, want %v", got.String(), tt.input)
+			}
+		})
+	}
+}
+
+func TestPeriod_Previous(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		wantStart string
+		wantEnd   string
+	}{
+		{name: "month across year", input: "2025-01", wantStart: "2024-12-01", wantEnd: "2025-01-01"},
+		{name: "week", input: "2025-W01", wantStart: "2024-12-23", wantEnd: "2024-12-30"},
+		{name: "quarter", input: "2025-Q1", wantStart: "2024-10-01", wantEnd: "2025-01-01"},
+		{name: "season keeps length", input: "2025-01-11..2025-01-20", wantStart: "2025-01-01", wantEnd: "2025-01-11"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := ParsePeriod(tt.input, time.UTC)
+			if err != nil {
+				t.Fatalf("ParsePeriod() error = %v", err)
+			}
+			got := p.Previous()
+			if got.StartDate() != tt.wantStart || got.EndDate() != tt.wantEnd {
+				t.Errorf("Previous() = [%s, %s), want [%s, %s)", got.StartDate(), got.EndDate(), tt.wantStart, tt.wantEnd)
+			}
+			if next := got.Next(); next.StartDate() != p.StartDate() || next.EndDate() != p.EndDate() {
+				t.Errorf("Previous().Next() = [%s, %s), want [%s, %s)", next.StartDate(), next.EndDate(), p.StartDate(), p.EndDate())
+			}
+		})
+	}
+}
+
+func TestPeriodRange(t *testing.T) {
+	tests := []struct {
+		name    string
+		from    string
+		to      string
+		want    []string
+		wantErr bool
+	}{
+		{name: "months", from: "2024-11", to: "2025-01", want: []string{"2024-11", "2024-12", "2025-01"}},
+		{name: "single period", from: "2025-Q1", to: "2025-Q1", want: []string{"2025-Q1"}},
+		{name: "seasons of equal length", from: "2025-01-01..2025-01-10", to: "2025-01-11..2025-01-20",
+			want: []string{"2025-01-01..2025-01-10", "2025-01-11..2025-01-20"}},
+		{name: "season stepped over", from: "2025-01-01..2025-01-10", to: "2025-01-05..2025-01-20", wantErr: true},
+		{name: "season of another length", from: "2025-01-01..2025-01-10", to: "2025-01-11..2025-01-31", wantErr: true},
+		{name: "reversed", from: "2025-02", to: "2025-01", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from, err := ParsePeriod(tt.from, time.UTC)
+			if err != nil {
+				t.Fatalf("ParsePeriod() error = %v", err)
+			}
+			to, err := ParsePeriod(tt.to, time.UTC)
+			if err != nil {
+				t.Fatalf("ParsePeriod() error = %v", err)
+			}
+			periods, err := PeriodRange(from, to)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("PeriodRange() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var got []string
+			for _, p := range periods {
+				got = append(got, p.String())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("PeriodRange() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGranularity_Calendar(t *testing.T) {
+	tests := []struct {
+		granularity Granularity
+		want        bool
+	}{
+		{GranularityWeek, true},
+		{GranularityMonth, true},
+		{GranularityQuarter, true},
+		{GranularityYear, true},
+		{GranularitySeason, false},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.granularity), func(t *testing.T) {
+			if got := tt.granularity.Calendar(); got != tt.want {
+				t.Errorf("Calendar() = %v, want %v", got, tt.want)
+			}
+			if _, err := NewPeriod(tt.granularity, time.Now()); (err == nil) != tt.want {
+				t.Errorf("NewPeriod() error = %v, want error %v", err, !tt.want)
+			}
+		})
+	}
+}
@@ -2,142 +2,336 @@ package postgres
 
 import (
 	"context"
-	"database/sql"
-	"errors"
 	"fmt"
 	"log"
-	"strconv"
+	"strings"
 	"time"
+
+	"github.com/jackc/pgx/v5"
 )
 
+// Коды типов наград из statistic.reward_type.code. Награды по формату игры
+// получают код с суффиксом формата, например "best_rating_1x1", см. GameTypeReward.
 const (
-	BEST_PLAYER_BY_RATING_MONTH_1x1 = "Лучший игрок месяца по рейтингу 1x1!"
-	BEST_PLAYER_BY_RATING_MONTH_2x2 = "Лучший игрок месяца по рейтингу 2x2!"
-	BEST_PLAYER_BY_RATING_MONTH_3x3 = "Лучший игрок месяца по рейтингу 3x3!"
-	BEST_PLAYER_BY_RATING_MONTH_4x4 = "Лучший игрок месяца по рейтингу 4x4!"
-	BEST_PLAYER_BY_RATING_MONTH_5x5 = "Лучший игрок месяца по рейтингу 5x5!"
-
-	WORST_PLAYER_BY_RATING_MONTH_1x1 = "Худший игрок месяца по рейтингу 1x1!"
-	WORST_PLAYER_BY_RATING_MONTH_2x2 = "Худший игрок месяца по рейтингу 2x2!"
-	WORST_PLAYER_BY_RATING_MONTH_3x3 = "Худший игрок месяца по рейтингу 3x3!"
-	WORST_PLAYER_BY_RATING_MONTH_4x4 = "Худший игрок месяца по рейтингу 4x4!"
-	WORST_PLAYER_BY_RATING_MONTH_5x5 = "Худший игрок месяца по рейтингу 5x5!"
-
-	TOP_WINRATE_MONTH       = "Лучший процент побед за месяц!"
-	MAX_LOSERATE_MONTH      = "Худший процент побед за месяц!"
-	TOP_GAINED_RATING_MONTH = "Максимальный прирост рейтинга за месяц!"
-	MAX_LOST_RATING_MONTH   = "Максимальная потеря рейтинга за месяц!"
-	MAX_GAMES_PLAYED_MONTH  = "Наибольшее количество сыгранных игр за месяц!"
-
-	LONGEST_WIN_STREAK_MONTH = "Самая длинная серия подряд за месяц!"
-	QueryInsertReward        = `
-        INSERT INTO statistic.reward (user_id, year, month, type, value, created_at)
-        VALUES ($1, $2, $3, $4, $5, NOW())
+	BEST_PLAYER_BY_RATING_MONTH  = "best_rating"
+	WORST_PLAYER_BY_RATING_MONTH = "worst_rating"
+
+	TOP_WINRATE_MONTH       = "top_winrate"
+	MAX_LOSERATE_MONTH      = "bottom_winrate"
+	TOP_GAINED_RATING_MONTH = "top_gained_rating"
+	MAX_LOST_RATING_MONTH   = "top_lost_rating"
+	MAX_GAMES_PLAYED_MONTH  = "max_games_played"
+
+	LONGEST_WIN_STREAK_MONTH   = "longest_win_streak"
+	LONGEST_LOSS_STREAK_MONTH  = "longest_loss_streak"
+	LONGEST_UNBEATEN_RUN_MONTH = "longest_unbeaten_run"
+	CURRENT_WIN_STREAK         = "current_win_streak"
+
+	WIN_STREAK_RECORD   = "win_streak_record"
+	UNBEATEN_RUN_RECORD = "unbeaten_run_record"
+)
+
+// DefaultLocale — язык названий наград по умолчанию.
+const DefaultLocale = "ru"
+
+// rewardTypeTitles — названия, под которыми типы наград создаются в statistic.reward_type.
+// Награды считаются за разные периоды, поэтому названия не называют период:
+// он хранится в награде отдельно.
+var rewardTypeTitles = map[string]string{
+	BEST_PLAYER_BY_RATING_MONTH:  "Лучший игрок по рейтингу!",
+	WORST_PLAYER_BY_RATING_MONTH: "Худший игрок по рейтингу!",
+	TOP_WINRATE_MONTH:            "Лучший процент побед!",
+	MAX_LOSERATE_MONTH:           "Худший процент побед!",
+	TOP_GAINED_RATING_MONTH:      "Максимальный прирост рейтинга!",
+	MAX_LOST_RATING_MONTH:        "Максимальная потеря рейтинга!",
+	MAX_GAMES_PLAYED_MONTH:       "Наибольшее количество сыгранных игр!",
+	LONGEST_WIN_STREAK_MONTH:     "Самая длинная серия побед!",
+	LONGEST_LOSS_STREAK_MONTH:    "Самая длинная серия поражений!",
+	LONGEST_UNBEATEN_RUN_MONTH:   "Самая длинная серия без поражений!",
+	CURRENT_WIN_STREAK:           "Самая длинная текущая серия побед!",
+	WIN_STREAK_RECORD:            "Новый рекорд серии побед!",
+	UNBEATEN_RUN_RECORD:          "Новый рекорд серии без поражений!",
+}
+
+const (
+	QueryInsertReward = `
+        INSERT INTO statistic.reward (user_id, period_start, period_end, granularity, type, rank, value, unit, details, method, sample_size, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NULLIF($10, ''), NULLIF($11, 0), NOW())
+        ON CONFLICT (period_start, period_end, type, rank, user_id) DO UPDATE
+        SET value = EXCLUDED.value,
+            unit = EXCLUDED.unit,
+            details = EXCLUDED.details,
+            method = EXCLUDED.method,
+            sample_size = EXCLUDED.sample_size,
+            created_at = EXCLUDED.created_at
         RETURNING id;
     `
 
+	QueryDeleteRewardEvidence = `
+        DELETE FROM statistic.reward_evidence WHERE reward_id = $1;
+    `
+
+	QueryInsertRewardEvidence = `
+        INSERT INTO statistic.reward_evidence (reward_id, game_id, position)
+        SELECT $1, e.game_id, e.position
+        FROM unnest($2::int[]) WITH ORDINALITY AS e(game_id, position);
+    `
+
+	// queryRewardTypeVariants выбирает тип награды с кодом $3 и все его
+	// варианты по формату игры.
+	queryRewardTypeVariants = `
+        SELECT id FROM statistic.reward_type
+        WHERE code = $3 OR code LIKE replace($3, '_', '\_') || '\_%'
+    `
+
+	QueryDeleteRewards = `
+        DELETE FROM statistic.reward
+        WHERE period_start = $1 AND period_end = $2
+          AND type IN (` + queryRewardTypeVariants + `);
+    `
+
 	QueryRewardTypeID = `
-        SELECT id FROM statistic.reward_type WHERE type = $1;
+        SELECT id FROM statistic.reward_type WHERE code = $1;
     `
+
+	QueryEnsureRewardType = `
+        INSERT INTO statistic.reward_type (code, type) VALUES ($1, $2)
+        ON CONFLICT (code) DO NOTHING;
+    `
+
+	// QueryEnsureGameTypeTranslations переводит награду по формату игры $1 на все
+	// языки базовой награды $2, добавляя к названию формат $3.
+	QueryEnsureGameTypeTranslations = `
+        INSERT INTO statistic.reward_type_translation (code, locale, title, description)
+        SELECT $1, locale, rtrim(title, '!') || ' ' || $3 || '!', description
+        FROM statistic.reward_type_translation
+        WHERE code = $2
+        ON CONFLICT (code, locale) DO NOTHING;
+    `
+
+	QueryGameTypes = `
+        SELECT DISTINCT type
+        FROM game.game
+        WHERE end_time IS NOT NULL
+          AND end_time >= $1 AND end_time < $2
+        ORDER BY type;
+    `
+
+	// QueryPeriodGames — общее для всех наград определение игр периода: игра
+	// относится к периоду по времени окончания g.end_time, незавершенные игры
+	// не учитываются. Одна строка — участие игрока в игре. Границы периода
+	// [start, end) передаются параметрами $1 и $2.
+	QueryPeriodGames = `
+        period_games AS (
+            SELECT
+                tm.user_id,
+                g.id AS game_id,
+                t.id AS team_id,
+                g.type,
+                g.end_time,
+                t.is_winner,
+                tm.new_rating,
+                tm.changed_rating
+            FROM
+                game.game g
+            JOIN
+                game.team t ON t.game_id = g.id
+            JOIN
+                game.team_members tm ON tm.team_id = t.id
+            WHERE
+                g.end_time IS NOT NULL
+                AND g.end_time >= $1 AND g.end_time < $2
+        )`
 )
 
-// SaveReward сохраняет награду пользователя в таблицу statistic.reward.
-func (q *DB) SaveReward(ctx context.Context, userID int, year, month string, rewardType string, value string) (int, error) {
+// SaveReward сохраняет награду победителя w за период в таблицу statistic.reward
+// вместе с единицей измерения, подробностями, методом расчета и размером выборки,
+// а игры, подтверждающие награду, — в statistic.reward_evidence.
+// Повторное сохранение за тот же период, тип и место заменяет прежний результат.
+func (q *DB) SaveReward(ctx context.Context, period Period, w Winner) (int, error) {
 	// Поиск ID типа награды
 	var rewardTypeID int
-	err := q.Conn.QueryRow(ctx, QueryRewardTypeID, rewardType).Scan(&rewardTypeID)
+	err := q.querier().QueryRow(ctx, QueryRewardTypeID, w.RewardType).Scan(&rewardTypeID)
 	if err != nil {
 		return 0, fmt.Errorf("failed to find reward type: %w", err)
 	}
 
-	// Вставка награды
+	// Вставка или обновление награды
 	var rewardID int
-	err = q.Conn.QueryRow(ctx, QueryInsertReward, userID, year, month, rewardTypeID, value).Scan(&rewardID)
+	err = q.querier().QueryRow(ctx, QueryInsertReward,
+		w.UserID, period.StartDate(), period.EndDate(), period.Granularity, rewardTypeID, w.Rank, w.Value, w.Unit, w.Details, w.Method, w.SampleSize,
+	).Scan(&rewardID)
 	if err != nil {
 		return 0, fmt.Errorf("failed to save reward: %w", err)
 	}
 
+	// Подтверждение награды заменяется целиком
+	if _, err := q.querier().Exec(ctx, QueryDeleteRewardEvidence, rewardID); err != nil {
+		return 0, fmt.Errorf("failed to clear reward evidence: %w", err)
+	}
+	if len(w.Evidence) > 0 {
+		if _, err := q.querier().Exec(ctx, QueryInsertRewardEvidence, rewardID, w.Evidence); err != nil {
+			return 0, fmt.Errorf("failed to save reward evidence: %w", err)
+		}
+	}
+
 	return rewardID, nil
 }
 
-func (conn *DB) TopRatingPerMonth(ctx context.Context, year string, month string) (err error) {
-	defer func() {
-		if r := recover(); r != nil {
-			err = fmt.Errorf("recovered from panic: %v", r)
-		}
-	}()
+// ClearRewards удаляет ранее сохраненные награды типа rewardType и всех его
+// вариантов по формату игры за период, чтобы пересчет заменял прежних
+// победителей, а не добавлял к ним новых.
+func (q *DB) ClearRewards(ctx context.Context, period Period, rewardType string) error {
+	if _, err := q.querier().Exec(ctx, QueryDeleteRewards, period.StartDate(), period.EndDate(), rewardType); err != nil {
+		return fmt.Errorf("failed to clear rewards: %w", err)
+	}
+	return nil
+}
 
-	query := `
-        WITH latest_rating AS (
-            SELECT
-                tm.user_id,
-                MAX(g.end_time) AS last_game_time
-            FROM
-                game.team_members tm
-            JOIN
-                game.team t ON tm.team_id = t.id    
-            JOIN
-                game.game g ON t.game_id = g.id
-            WHERE
-                g.type = $1
-                AND DATE_TRUNC('month', g.end_time) = DATE_TRUNC('month', $2::date)
-            GROUP BY
-                tm.user_id
-        ),
-        user_ratings AS (
-            SELECT
-                tm.user_id,
-                tm.new_rating AS rating
-            FROM
-                game.team_members tm
-            JOIN
-                game.team t ON tm.team_id = t.id
-            JOIN
-                game.game g ON t.game_id = g.id
-            JOIN
-                latest_rating lr ON tm.user_id = lr.user_id AND g.end_time = lr.last_game_time
-            WHERE
-                g.type = $1
-        )
-        SELECT
-            ur.user_id,
-            ur.rating
-        FROM
-            user_ratings ur
-        JOIN
-            account.user u ON ur.user_id = u.id
-        ORDER BY
-            ur.rating DESC
-        LIMIT 1
-    `
+// EnsureRewardType добавляет тип награды с кодом code в statistic.reward_type,
+// если его еще нет. Название типа берется из RewardTypeTitle, а переводы
+// награды по формату игры — из переводов базовой награды. Для награды по
+// формату игры базовый тип создается, даже если сама базовая награда не
+// сохраняется, как у наград за рейтинг.
+func (q *DB) EnsureRewardType(ctx context.Context, code string) error {
+	if _, err := q.querier().Exec(ctx, QueryEnsureRewardType, code, RewardTypeTitle(code)); err != nil {
+		return fmt.Errorf("failed to ensure reward type: %w", err)
+	}
+	if base, gameType, ok := splitRewardCode(code); ok {
+		if _, err := q.querier().Exec(ctx, QueryEnsureRewardType, base, RewardTypeTitle(base)); err != nil {
+			return fmt.Errorf("failed to ensure reward type: %w", err)
+		}
+		if _, err := q.querier().Exec(ctx, QueryEnsureGameTypeTranslations, code, base, gameType); err != nil {
+			return fmt.Errorf("failed to ensure reward type translations: %w", err)
+		}
+	}
+	return nil
+}
 
-	typesName := []string{BEST_PLAYER_BY_RATING_MONTH_1x1, BEST_PLAYER_BY_RATING_MONTH_2x2, BEST_PLAYER_BY_RATING_MONTH_3x3, BEST_PLAYER_BY_RATING_MONTH_4x4, BEST_PLAYER_BY_RATING_MONTH_5x5}
-	types := []string{"1x1", "2x2", "3x3", "4x4", "5x5"}
-	date := fmt.Sprintf("%s-%s-01", year, month)
+// GameTypes возвращает форматы завершенных игр периода, например "1x1" или "3x3".
+func (q *DB) GameTypes(ctx context.Context, period Period) ([]string, error) {
+	rows, err := q.querier().Query(ctx, QueryGameTypes, period.Start, period.End)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find game types: %w", err)
+	}
+	types, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("failed to find game types: %w", err)
+	}
+	return types, nil
+}
 
-	for i, t := range typesName {
-		var userID int
-		var maxRating float64
+// GameTypeReward возвращает код награды rewardType для формата игры gameType:
+// "top_winrate" для "1x1" становится "top_winrate_1x1". Пустой формат означает все игры.
+func GameTypeReward(rewardType, gameType string) string {
+	if gameType == "" {
+		return rewardType
+	}
+	return rewardType + "_" + gameType
+}
 
-		err = conn.Conn.QueryRow(ctx, query, types[i], date).Scan(&userID, &maxRating)
+// RewardTypeTitle возвращает название типа награды по коду. Для награды по
+// формату игры формат добавляется к названию: "top_winrate_1x1" получает
+// название "Лучший процент побед 1x1!". Для неизвестного кода
+// возвращается сам код.
+func RewardTypeTitle(code string) string {
+	if title, ok := rewardTypeTitles[code]; ok {
+		return title
+	}
+	if base, gameType, ok := splitRewardCode(code); ok {
+		return strings.TrimSuffix(rewardTypeTitles[base], "!") + " " + gameType + "!"
+	}
+	return code
+}
 
-		if errors.Is(err, sql.ErrNoRows) {
-			log.Printf("No data found for type: %s and date: %s", types[i], date)
-			continue
-		} else if err != nil {
-			return fmt.Errorf("failed to find top user for type %s: %w", types[i], err)
+// splitRewardCode разбирает код награды по формату игры на код базовой
+// награды и формат. Коды базовых наград не являются префиксами друг друга,
+// поэтому подходящий префикс не больше одного.
+func splitRewardCode(code string) (base, gameType string, ok bool) {
+	for base := range rewardTypeTitles {
+		if gameType, ok := strings.CutPrefix(code, base+"_"); ok {
+			return base, gameType, true
 		}
+	}
+	return "", "", false
+}
+
+// byGameType вычисляет кандидатов отдельно по каждому формату игры периода,
+// а если overall — еще и за все игры. query получает формат игры (пустая
+// строка означает все игры) и тип награды, которым нужно пометить кандидатов.
+func (conn *DB) byGameType(ctx context.Context, period Period, rewardType string, overall bool, query func(gameType, rewardType string) ([]Candidate, error)) ([]Candidate, error) {
+	gameTypes, err := conn.GameTypes(ctx, period)
+	if err != nil {
+		return nil, err
+	}
+	if overall {
+		gameTypes = append([]string{""}, gameTypes...)
+	}
 
-		maxRatingString := strconv.Itoa(int(maxRating))
-		if _, err = conn.SaveReward(ctx, userID, year, month, t, maxRatingString); err != nil {
-			return fmt.Errorf("failed to save reward for type %s: %w", types[i], err)
+	var candidates []Candidate
+	for _, gameType := range gameTypes {
+		typeCandidates, err := query(gameType, GameTypeReward(rewardType, gameType))
+		if err != nil {
+			return nil, err
+		}
+		for i := range typeCandidates {
+			typeCandidates[i].GameType = gameType
 		}
+		candidates = append(candidates, typeCandidates...)
 	}
+	return candidates, nil
+}
 
-	return nil
+// queryCandidates выполняет запрос кандидатов и помечает их типом награды rewardType.
+func (conn *DB) queryCandidates(ctx context.Context, query string, rewardType string, args ...any) ([]Candidate, error) {
+	rows, err := conn.querier().Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return scanCandidates(rows, rewardType)
+}
+
+// candidateRow — строка запроса кандидатов. Столбцы games, wins, game_ids и
+// started_at необязательны.
+type candidateRow struct {
+	UserID     int       `db:"user_id"`
+	Value      float64   `db:"value"`
+	AchievedAt time.Time `db:"achieved_at"`
+	StartedAt  time.Time `db:"started_at"`
+	Games      int       `db:"games"`
+	Wins       int       `db:"wins"`
+	GameIDs    []int     `db:"game_ids"`
+}
+
+// scanCandidates читает строки со столбцами user_id, value, achieved_at и
+// необязательными games, wins, game_ids и started_at и помечает каждого
+// кандидата типом награды rewardType.
+func scanCandidates(rows pgx.Rows, rewardType string) ([]Candidate, error) {
+	found, err := pgx.CollectRows(rows, pgx.RowToStructByNameLax[candidateRow])
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rows: %w", err)
+	}
+
+	candidates := make([]Candidate, 0, len(found))
+	for _, row := range found {
+		candidates = append(candidates, Candidate{
+			UserID:     row.UserID,
+			RewardType: rewardType,
+			Value:      row.Value,
+			AchievedAt: row.AchievedAt,
+			StartedAt:  row.StartedAt,
+			Games:      row.Games,
+			Wins:       row.Wins,
+			GameIDs:    row.GameIDs,
+		})
+	}
+	return candidates, nil
 }
 
-func (conn *DB) WorstRatingPerMonth(ctx context.Context, year string, month string) (err error) {
+// FinalRatings возвращает итоговый рейтинг каждого игрока за период по каждому
+// формату игры, сыгранному в периоде, и помечает кандидатов типом награды
+// rewardType. Лучший или худший рейтинг выбирается при ранжировании награды.
+func (conn *DB) FinalRatings(ctx context.Context, period Period, rewardType string) (candidates []Candidate, err error) {
 	defer func() {
 		if r := recover(); r != nil {
 			err = fmt.Errorf("recovered from panic: %v", r)
@@ -145,196 +339,67 @@ func (conn *DB) WorstRatingPerMonth(ctx context.Context, year string, month stri
 	}()
 
 	query := `
-        WITH latest_rating AS (
+        WITH ` + QueryPeriodGames + `,
+        latest_rating AS (
             SELECT
-                tm.user_id,
-                MAX(g.end_time) AS last_game_time
+                user_id,
+                MAX(end_time) AS last_game_time,
+                COUNT(DISTINCT game_id) AS games_played
             FROM
-                game.team_members tm
-            JOIN
-                game.team t ON tm.team_id = t.id    
-            JOIN
-                game.game g ON t.game_id = g.id
+                period_games
             WHERE
-                g.type = $1
-                AND DATE_TRUNC('month', g.end_time) = DATE_TRUNC('month', $2::date)
+                type = $3
             GROUP BY
-                tm.user_id
+                user_id
         ),
         user_ratings AS (
             SELECT
-                tm.user_id,
-                tm.new_rating AS rating
+                pg.user_id,
+                pg.game_id,
+                pg.new_rating AS rating,
+                lr.last_game_time,
+                lr.games_played
             FROM
-                game.team_members tm
-            JOIN
-                game.team t ON tm.team_id = t.id
+                period_games pg
             JOIN
-                game.game g ON t.game_id = g.id
-            JOIN
-                latest_rating lr ON tm.user_id = lr.user_id AND g.end_time = lr.last_game_time
+                latest_rating lr ON pg.user_id = lr.user_id AND pg.end_time = lr.last_game_time
             WHERE
-                g.type = $1
+                pg.type = $3
         )
         SELECT
             ur.user_id,
-            ur.rating
+            ur.rating AS value,
+            ur.last_game_time AS achieved_at,
+            ur.games_played AS games,
+            ARRAY[ur.game_id] AS game_ids -- Игра, по итогам которой установлен рейтинг
         FROM
             user_ratings ur
         JOIN
             account.user u ON ur.user_id = u.id
-        ORDER BY
-            ur.rating ASC
-        LIMIT 1
     `
 
-	typesName := []string{WORST_PLAYER_BY_RATING_MONTH_1x1, WORST_PLAYER_BY_RATING_MONTH_2x2, WORST_PLAYER_BY_RATING_MONTH_3x3, WORST_PLAYER_BY_RATING_MONTH_4x4, WORST_PLAYER_BY_RATING_MONTH_5x5}
-	types := []string{"1x1", "2x2", "3x3", "4x4", "5x5"}
-	date := fmt.Sprintf("%s-%s-01", year, month)
-
-	for i, t := range typesName {
-		var userID int
-		var minRating float64
-
-		err = conn.Conn.QueryRow(ctx, query, types[i], date).Scan(&userID, &minRating)
-
-		if errors.Is(err, sql.ErrNoRows) {
-			log.Printf("No data found for type: %s and date: %s", types[i], date)
-			continue
-		} else if err != nil {
-			return fmt.Errorf("failed to find worst user for type %s: %w", types[i], err)
+	candidates, err = conn.byGameType(ctx, period, rewardType, false, func(gameType, rewardType string) ([]Candidate, error) {
+		typeCandidates, err := conn.queryCandidates(ctx, query, rewardType, period.Start, period.End, gameType)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find ratings for type %s: %w", gameType, err)
 		}
-
-		minRatingString := strconv.Itoa(int(minRating))
-		if _, err = conn.SaveReward(ctx, userID, year, month, t, minRatingString); err != nil {
-			return fmt.Errorf("failed to save reward for type %s: %w", types[i], err)
+		if len(typeCandidates) == 0 {
+			log.Printf("No data found for type: %s and period: %s", gameType, period)
 		}
-	}
-
-	return nil
-}
-func (conn *DB) TopWinratePerMonth(ctx context.Context, year string, month string) (err error) {
-	// Используем defer для перехвата паники
-	defer func() {
-		if r := recover(); r != nil {
-			err = fmt.Errorf("recovered from panic: %v", r)
-		}
-	}()
-
-	// Запрос для получения худшего пользователя за месяц
-	query := `
-		WITH winrates AS (
-			SELECT
-				(SUM(CASE WHEN is_winner THEN 1 ELSE 0 END)::FLOAT / COUNT(t.game_id)::FLOAT) AS winrate,
-				SUM(CASE WHEN is_winner THEN 1 ELSE 0 END) AS win,
-				COUNT(t.game_id) AS total,
-				u.id,
-				u.first_name,
-				u.last_name,
-				u.number,
-				u.icon
-			FROM
-				game.team t
-			JOIN
-				game.team_members tm ON t.id = tm.team_id
-			JOIN
-				account.user u ON tm.user_id = u.id
-            WHERE
-                DATE_TRUNC('month', t.created_at) = DATE_TRUNC('month', $1::date)
-			GROUP BY
-				u.id
-		)
-		SELECT
-			winrate,
-			id
-		FROM
-			winrates
-		WHERE
-			total >= 10 -- Минимальное количество игр для участия в рейтинге
-		ORDER BY
-			winrate DESC
-		LIMIT 1;
-    `
-
-	// Подготовка даты для фильтрации
-	date := fmt.Sprintf("%s-%s-01", year, month) // Формат: YYYY-MM-01
-
-	// Выполнение запроса
-	var userID int
-	var WinRate float64
-	err = conn.Conn.QueryRow(ctx, query, date).Scan(&WinRate, &userID)
+		return typeCandidates, nil
+	})
 	if err != nil {
-		return fmt.Errorf("failed to find worst user: %w", err)
+		return nil, err
 	}
-	winRateString := strconv.FormatFloat(WinRate, 'g', 2, 64)
-	_, err = conn.SaveReward(ctx, userID, year, month, TOP_WINRATE_MONTH, winRateString)
-	if err != nil {
-		return fmt.Errorf("failed to save top user: %w", err)
-	}
-	return nil
-}
-func (conn *DB) BottomWinratePerMonth(ctx context.Context, year string, month string) (err error) {
-	// Используем defer для перехвата паники
-	defer func() {
-		if r := recover(); r != nil {
-			err = fmt.Errorf("recovered from panic: %v", r)
-		}
-	}()
-
-	// Запрос для получения худшего пользователя за месяц
-	query := `
-		WITH winrates AS (
-			SELECT
-				(SUM(CASE WHEN is_winner THEN 1 ELSE 0 END)::FLOAT / COUNT(t.game_id)::FLOAT) AS winrate,
-				SUM(CASE WHEN is_winner THEN 1 ELSE 0 END) AS win,
-				COUNT(t.game_id) AS total,
-				u.id,
-				u.first_name,
-				u.last_name,
-				u.number,
-				u.icon
-			FROM
-				game.team t
-			JOIN
-				game.team_members tm ON t.id = tm.team_id
-			JOIN
-				account.user u ON tm.user_id = u.id
-            WHERE
-                DATE_TRUNC('month', t.created_at) = DATE_TRUNC('month', $1::date)
-			GROUP BY
-				u.id
-		)
-		SELECT
-			winrate,
-			id
-		FROM
-			winrates
-		WHERE
-			total >= 10 -- Минимальное количество игр для участия в рейтинге
-		ORDER BY
-			winrate ASC
-		LIMIT 1;
-    `
-
-	// Подготовка даты для фильтрации
-	date := fmt.Sprintf("%s-%s-01", year, month) // Формат: YYYY-MM-01
 
-	// Выполнение запроса
-	var userID int
-	var WinRate float64
-	err = conn.Conn.QueryRow(ctx, query, date).Scan(&WinRate, &userID)
-	if err != nil {
-		return fmt.Errorf("failed to find worst user: %w", err)
-	}
-	winRateString := strconv.FormatFloat(WinRate, 'g', 2, 64)
-	_, err = conn.SaveReward(ctx, userID, year, month, MAX_LOSERATE_MONTH, winRateString)
-	if err != nil {
-		return fmt.Errorf("failed to save top user: %w", err)
-	}
-	return nil
+	return candidates, nil
 }
 
-func (conn *DB) TopGainedRatingMonth(ctx context.Context, year string, month string) (err error) {
+// Winrates возвращает процент побед игроков за период по всем играм и
+// отдельно по каждому формату игры и помечает кандидатов типом награды
+// rewardType. Порог по количеству игр, оценка процента побед и направление
+// ранжирования применяются при расчете награды.
+func (conn *DB) Winrates(ctx context.Context, period Period, rewardType string) (candidates []Candidate, err error) {
 	// Используем defer для перехвата паники
 	defer func() {
 		if r := recover(); r != nil {
@@ -342,56 +407,50 @@ func (conn *DB) TopGainedRatingMonth(ctx context.Context, year string, month str
 		}
 	}()
 
-	// Запрос для получения худшего пользователя за месяц
 	query := `
-		WITH user_stats AS (
+		WITH ` + QueryPeriodGames + `,
+		winrates AS (
 			SELECT
-				sum(changed_rating) AS maximum_gained,
-				COUNT(t.game_id) AS total_games,
-				u.id,
-				u.first_name,
-				u.last_name,
-				u.number,
-				u.icon
+				(SUM(CASE WHEN pg.is_winner THEN 1 ELSE 0 END)::FLOAT / COUNT(pg.game_id)::FLOAT) AS winrate,
+				SUM(CASE WHEN pg.is_winner THEN 1 ELSE 0 END) AS win,
+				COUNT(pg.game_id) AS total,
+				MAX(pg.end_time) AS last_game_time,
+				array_agg(pg.game_id ORDER BY pg.end_time, pg.game_id) AS game_ids,
+				u.id
 			FROM
-				game.team t
-			JOIN
-				game.team_members tm ON t.id = tm.team_id
+				period_games pg
 			JOIN
-				account.user u ON tm.user_id = u.id
-			 WHERE
-				DATE_TRUNC('month', t.created_at) = DATE_TRUNC('month', $1::date)
+				account.user u ON pg.user_id = u.id
+			WHERE
+				$3::text = '' OR pg.type = $3
 			GROUP BY
-				u.id, u.first_name, u.last_name, u.number, u.icon
+				u.id
 		)
 		SELECT
-			maximum_gained,
-			id
+			id AS user_id,
+			winrate AS value,
+			last_game_time AS achieved_at,
+			total AS games,
+			win AS wins,
+			game_ids
 		FROM
-			user_stats
-		WHERE
-			maximum_gained = (SELECT MAX(maximum_gained) FROM user_stats);
+			winrates;
     `
 
-	// Подготовка даты для фильтрации
-	date := fmt.Sprintf("%s-%s-01", year, month) // Формат: YYYY-MM-01
-
-	// Выполнение запроса
-	var userID int
-	var TopRatingGained int
-	err = conn.Conn.QueryRow(ctx, query, date).Scan(&TopRatingGained, &userID)
-	if err != nil {
-		return fmt.Errorf("failed to find worst user: %w", err)
-	}
-	TopRatingGainedString := strconv.Itoa(TopRatingGained)
-	_, err = conn.SaveReward(ctx, userID, year, month, TOP_GAINED_RATING_MONTH, TopRatingGainedString)
+	// Выполнение запроса за период [start, end) по всем играм и по каждому формату
+	candidates, err = conn.byGameType(ctx, period, rewardType, true, func(gameType, rewardType string) ([]Candidate, error) {
+		return conn.queryCandidates(ctx, query, rewardType, period.Start, period.End, gameType)
+	})
 	if err != nil {
-		return fmt.Errorf("failed to save top user: %w", err)
+		return nil, fmt.Errorf("failed to find winrates: %w", err)
 	}
-	return nil
+	return candidates, nil
 }
 
-func (conn *DB) TopLostRatingMonth(ctx context.Context, year string, month string) (err error) {
+// RatingChanges возвращает суммарное изменение рейтинга игроков за период по
+// всем играм и по каждому формату игры и помечает кандидатов типом награды
+// rewardType. Прирост или потеря выбирается при ранжировании награды.
+func (conn *DB) RatingChanges(ctx context.Context, period Period, rewardType string) (candidates []Candidate, err error) {
 	// Используем defer для перехвата паники
 	defer func() {
 		if r := recover(); r != nil {
@@ -399,56 +458,47 @@ func (conn *DB) TopLostRatingMonth(ctx context.Context, year string, month strin
 		}
 	}()
 
-	// Запрос для получения худшего пользователя за месяц
 	query := `
-		WITH user_stats AS (
+		WITH ` + QueryPeriodGames + `,
+		user_stats AS (
 			SELECT
-				sum(changed_rating) AS maximum_gained,
-				COUNT(t.game_id) AS total_games,
-				u.id,
-				u.first_name,
-				u.last_name,
-				u.number,
-				u.icon
+				sum(pg.changed_rating) AS rating_change,
+				COUNT(pg.game_id) AS total_games,
+				MAX(pg.end_time) AS last_game_time,
+				array_agg(pg.game_id ORDER BY pg.end_time, pg.game_id) AS game_ids,
+				u.id
 			FROM
-				game.team t
-			JOIN
-				game.team_members tm ON t.id = tm.team_id
+				period_games pg
 			JOIN
-				account.user u ON tm.user_id = u.id
-			 WHERE
-				DATE_TRUNC('month', t.created_at) = DATE_TRUNC('month', $1::date)
+				account.user u ON pg.user_id = u.id
+			WHERE
+				$3::text = '' OR pg.type = $3
 			GROUP BY
-				u.id, u.first_name, u.last_name, u.number, u.icon
+				u.id
 		)
 		SELECT
-			maximum_gained,
-			id
+			id AS user_id,
+			rating_change::FLOAT AS value,
+			last_game_time AS achieved_at,
+			total_games AS games,
+			game_ids
 		FROM
-			user_stats
-		WHERE
-			maximum_gained = (SELECT MIN(maximum_gained) FROM user_stats);
+			user_stats;
     `
 
-	// Подготовка даты для фильтрации
-	date := fmt.Sprintf("%s-%s-01", year, month) // Формат: YYYY-MM-01
-
-	// Выполнение запроса
-	var userID int
-	var TopLoseRating int
-	err = conn.Conn.QueryRow(ctx, query, date).Scan(&TopLoseRating, &userID)
+	// Выполнение запроса за период [start, end) по всем играм и по каждому формату
+	candidates, err = conn.byGameType(ctx, period, rewardType, true, func(gameType, rewardType string) ([]Candidate, error) {
+		return conn.queryCandidates(ctx, query, rewardType, period.Start, period.End, gameType)
+	})
 	if err != nil {
-		return fmt.Errorf("failed to find worst user: %w", err)
+		return nil, fmt.Errorf("failed to find rating changes: %w", err)
 	}
-	TopLoseRatingString := strconv.Itoa(TopLoseRating)
-	_, err = conn.SaveReward(ctx, userID, year, month, MAX_LOST_RATING_MONTH, TopLoseRatingString)
-	if err != nil {
-		return fmt.Errorf("failed to save top user: %w", err)
-	}
-	return nil
+	return candidates, nil
 }
 
-func (conn *DB) MaxGamesPlayed(ctx context.Context, year string, month string) (err error) {
+// MaxGamesPlayed возвращает количество сыгранных игр каждого игрока за период
+// по всем играм и по каждому формату игры и помечает кандидатов типом награды rewardType.
+func (conn *DB) MaxGamesPlayed(ctx context.Context, period Period, rewardType string) (candidates []Candidate, err error) {
 	// Используем defer для перехвата паники
 	defer func() {
 		if r := recover(); r != nil {
@@ -456,158 +506,39 @@ func (conn *DB) MaxGamesPlayed(ctx context.Context, year string, month string) (
 		}
 	}()
 
-	// Запрос для получения худшего пользователя за месяц
 	query := `
-		WITH user_game_stats AS (
+		WITH ` + QueryPeriodGames + `,
+		user_game_stats AS (
 			SELECT
-				COUNT(DISTINCT g.id) AS games_played, -- Подсчитываем уникальные игры
-				tm.user_id AS user_id
+				COUNT(DISTINCT game_id) AS games_played, -- Подсчитываем уникальные игры
+				MAX(end_time) AS last_game_time,
+				array_agg(DISTINCT game_id ORDER BY game_id) AS game_ids,
+				user_id
 			FROM
-				game.team_members tm
-			JOIN
-				game.team t ON t.id = tm.team_id
-			JOIN
-				game.game g ON g.id = t.game_id
+				period_games
 			WHERE
-				DATE_TRUNC('month', g.end_time) = DATE_TRUNC('month', $1::date)
+				$3::text = '' OR type = $3
 			GROUP BY
-				tm.user_id -- Группируем только по пользователю
+				user_id -- Группируем только по пользователю
 		)
 		SELECT
-			ugs.games_played,
-			u.id
+			u.id AS user_id,
+			ugs.games_played::FLOAT AS value,
+			ugs.last_game_time AS achieved_at,
+			ugs.games_played AS games,
+			ugs.game_ids
 		FROM
 			user_game_stats ugs
 		JOIN
-			account.user u ON ugs.user_id = u.id
-		ORDER BY
-			ugs.games_played DESC
-		LIMIT 1;
+			account.user u ON ugs.user_id = u.id;
     `
 
-	// Подготовка даты для фильтрации
-	date := fmt.Sprintf("%s-%s-01", year, month) // Формат: YYYY-MM-01
-
-	// Выполнение запроса
-	var userID int
-	var TopGamesPlayed int
-	err = conn.Conn.QueryRow(ctx, query, date).Scan(&TopGamesPlayed, &userID)
-	if err != nil {
-		return fmt.Errorf("failed to find worst user: %w", err)
-	}
-	TopGamesPlayedString := strconv.Itoa(TopGamesPlayed)
-	_, err = conn.SaveReward(ctx, userID, year, month, MAX_GAMES_PLAYED_MONTH, TopGamesPlayedString)
+	// Выполнение запроса за период [start, end) по всем играм и по каждому формату
+	candidates, err = conn.byGameType(ctx, period, rewardType, true, func(gameType, rewardType string) ([]Candidate, error) {
+		return conn.queryCandidates(ctx, query, rewardType, period.Start, period.End, gameType)
+	})
 	if err != nil {
-		return fmt.Errorf("failed to save top user: %w", err)
+		return nil, fmt.Errorf("failed to find top user: %w", err)
 	}
-	return nil
-}
-
-func (conn DB) LongestWinStreak(ctx context.Context, year string, month string) error {
-	var err error
-	defer func() {
-		if r := recover(); r != nil {
-			err = fmt.Errorf("recovered from panic: %v", r)
-		}
-	}()
-
-	query := `
-    SELECT
-        user_id, is_winner, t.created_at
-    FROM
-        game.team_members tm
-    JOIN
-        game.team t ON tm.team_id = t.id
-    JOIN
-        game.game g ON t.game_id = g.id
-    WHERE
-        DATE_TRUNC('month', g.end_time) = DATE_TRUNC('month', $1::date)
-    ORDER BY
-        g.end_time ASC -- Важно сортировать по времени в хронологическом порядке
-    `
-
-	date := fmt.Sprintf("%s-%s-01", year, month)
-	rows, err := conn.Conn.Query(ctx, query, date)
-	if err != nil {
-		return fmt.Errorf("failed to execute query: %w", err)
-	}
-	defer rows.Close()
-
-	// Используем указатели на структуры для хранения данных
-	type userGameRecord struct {
-		UserID    int
-		WinStreak []bool
-	}
-	userData := make(map[int]*userGameRecord)
-
-	for rows.Next() {
-		var (
-			userID   int
-			isWinner bool
-			gameTime time.Time
-		)
-		err := rows.Scan(&userID, &isWinner, &gameTime)
-		if err != nil {
-			return fmt.Errorf("failed to scan row: %w", err)
-		}
-
-		// Инициализируем запись, если её нет
-		if _, exists := userData[userID]; !exists {
-			userData[userID] = &userGameRecord{
-				UserID:    userID,
-				WinStreak: make([]bool, 0),
-			}
-		}
-
-		// Добавляем результат игры в WinStreak
-		userData[userID].WinStreak = append(userData[userID].WinStreak, isWinner)
-	}
-
-	// Подсчет максимальной серии побед
-	var maxStreakUser struct {
-		UserID int
-		Length int
-	}
-
-	for _, record := range userData {
-		currentStreak := 0
-		maxStreak := 0
-
-		for _, win := range record.WinStreak {
-			if win {
-				currentStreak++
-				if currentStreak > maxStreak {
-					maxStreak = currentStreak
-				}
-			} else {
-				currentStreak = 0
-			}
-		}
-
-		if maxStreak > maxStreakUser.Length {
-			maxStreakUser.UserID = record.UserID
-			maxStreakUser.Length = maxStreak
-		}
-	}
-
-	// Сохранение награды, если есть победы
-	if maxStreakUser.Length > 0 {
-		streakValue := strconv.Itoa(maxStreakUser.Length)
-		_, saveErr := conn.SaveReward(
-			ctx,
-			maxStreakUser.UserID,
-			year,
-			month,
-			LONGEST_WIN_STREAK_MONTH, // Добавьте эту константу в начале файла
-			streakValue,
-		)
-		if saveErr != nil {
-			return fmt.Errorf("failed to save longest win streak: %w", saveErr)
-		}
-		log.Printf("Longest win streak saved for user %d (length %d)", maxStreakUser.UserID, maxStreakUser.Length)
-	} else {
-		log.Println("No winning streak found for the month")
-	}
-
-	return nil
+	return candidates, nil
 }
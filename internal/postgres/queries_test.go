@@ -2,9 +2,15 @@ package postgres
 
 import (
 	"context"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"os"
+	"path/filepath"
 	"reflect"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 func TestDB_Close(t *testing.T) {
@@ -27,14 +33,14 @@ func TestDB_Close(t *testing.T) {
 	}
 }
 
-func TestDB_TopRatingPerMonth(t *testing.T) {
+func TestDB_FinalRatings(t *testing.T) {
 	type fields struct {
 		Conn *pgxpool.Pool
 	}
 	type args struct {
-		ctx   context.Context
-		year  string
-		month string
+		ctx        context.Context
+		period     Period
+		rewardType string
 	}
 	tests := []struct {
 		name    string
@@ -49,63 +55,806 @@ func TestDB_TopRatingPerMonth(t *testing.T) {
 			conn := &DB{
 				Conn: tt.fields.Conn,
 			}
-			if err := conn.TopRatingPerMonth(tt.args.ctx, tt.args.year, tt.args.month); (err != nil) != tt.wantErr {
-				t.Errorf("TopRatingPerMonth() error = %v, wantErr %v", err, tt.wantErr)
+			if _, err := conn.FinalRatings(tt.args.ctx, tt.args.period, tt.args.rewardType); (err != nil) != tt.wantErr {
+				t.Errorf("FinalRatings() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
 	}
 }
 
-func TestDB_WorstRatingPerMonth(t *testing.T) {
-	type fields struct {
-		Conn *pgxpool.Pool
-	}
+func TestNewDB(t *testing.T) {
 	type args struct {
-		ctx   context.Context
-		year  string
-		month string
+		url string
+		loc *time.Location
 	}
 	tests := []struct {
 		name    string
-		fields  fields
 		args    args
+		want    *DB
 		wantErr bool
 	}{
-		// TODO: Add test cases.
+		{name: "local timezone", args: args{url: "postgres://localhost:5432/postgres", loc: time.Local}, want: nil, wantErr: true},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			conn := &DB{
-				Conn: tt.fields.Conn,
+			got, err := NewDB(tt.args.url, tt.args.loc)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewDB() error = %v, wantErr %v", err, tt.wantErr)
+				return
 			}
-			if err := conn.WorstRatingPerMonth(tt.args.ctx, tt.args.year, tt.args.month); (err != nil) != tt.wantErr {
-				t.Errorf("WorstRatingPerMonth() error = %v, wantErr %v", err, tt.wantErr)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NewDB() got = %v, want %v", got, tt.want)
 			}
 		})
 	}
 }
 
-func TestNewDB(t *testing.T) {
-	type args struct {
-		url string
+// fixtures создает минимальную схему игр и заполняет её данными за
+// несколько лет, чтобы выборка за один месяц была избирательной.
+const fixtures = `
+    CREATE SCHEMA IF NOT EXISTS account;
+    CREATE SCHEMA IF NOT EXISTS game;
+
+    CREATE TABLE account."user" (
+        id serial PRIMARY KEY,
+        first_name text NOT NULL,
+        last_name text NOT NULL,
+        number text,
+        icon text
+    );
+    CREATE TABLE game.game (
+        id serial PRIMARY KEY,
+        type text NOT NULL,
+        end_time timestamptz
+    );
+    CREATE TABLE game.team (
+        id serial PRIMARY KEY,
+        game_id int NOT NULL REFERENCES game.game (id),
+        is_winner boolean NOT NULL,
+        created_at timestamptz NOT NULL
+    );
+    CREATE TABLE game.team_members (
+        id serial PRIMARY KEY,
+        team_id int NOT NULL REFERENCES game.team (id),
+        user_id int NOT NULL REFERENCES account."user" (id),
+        new_rating float8 NOT NULL,
+        changed_rating int NOT NULL
+    );
+
+    INSERT INTO account."user" (first_name, last_name, number, icon)
+    SELECT 'first' || i, 'last' || i, i::text, '' FROM generate_series(1, 200) i;
+
+    INSERT INTO game.game (type, end_time)
+    SELECT (ARRAY['1x1', '2x2', '3x3', '4x4', '5x5'])[1 + i % 5], timestamptz '2022-01-01 00:00:00+00' + i * interval '1 hour'
+    FROM generate_series(1, 30000) i;
+
+    INSERT INTO game.team (game_id, is_winner, created_at)
+    SELECT g.id, side = 1, g.end_time - interval '30 minutes'
+    FROM game.game g, generate_series(1, 2) side;
+
+    -- Незавершенные игры, команды которых созданы посреди месяца
+    INSERT INTO game.game (type, end_time)
+    SELECT '1x1', NULL FROM generate_series(1, 50);
+
+    INSERT INTO game.team (game_id, is_winner, created_at)
+    SELECT g.id, side = 1, timestamptz '2024-06-15 12:00:00+00'
+    FROM game.game g, generate_series(1, 2) side
+    WHERE g.end_time IS NULL;
+
+    INSERT INTO game.team_members (team_id, user_id, new_rating, changed_rating)
+    SELECT t.id, 1 + (t.id * 7 + slot) % 200, 1000 + t.id % 500, CASE WHEN t.is_winner THEN 10 ELSE -10 END
+    FROM game.team t, generate_series(1, 2) slot;
+
+    ANALYZE account."user", game.game, game.team, game.team_members;
+`
+
+// recordedQuery — запрос, выполненный через пул во время теста.
+type recordedQuery struct {
+	sql  string
+	args []any
+}
+
+// queryRecorder запоминает все запросы, проходящие через пул соединений.
+type queryRecorder struct {
+	mu      sync.Mutex
+	queries []recordedQuery
+}
+
+func (r *queryRecorder) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.queries = append(r.queries, recordedQuery{sql: data.SQL, args: data.Args})
+	return ctx
+}
+
+func (r *queryRecorder) TraceQueryEnd(context.Context, *pgx.Conn, pgx.TraceQueryEndData) {}
+
+// take возвращает запомненные запросы и очищает список.
+func (r *queryRecorder) take() []recordedQuery {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	queries := r.queries
+	r.queries = nil
+	return queries
+}
+
+// seqScans возвращает таблицы из relations, которые план читает последовательным сканированием.
+func seqScans(node map[string]any, relations map[string]bool) []string {
+	var found []string
+	if node["Node Type"] == "Seq Scan" {
+		if name, _ := node["Relation Name"].(string); relations[name] {
+			found = append(found, name)
+		}
+	}
+	children, _ := node["Plans"].([]any)
+	for _, child := range children {
+		if plan, ok := child.(map[string]any); ok {
+			found = append(found, seqScans(plan, relations)...)
+		}
+	}
+	return found
+}
+
+// newFixtureDB открывает транзакцию в тестовой базе TEST_POSTGRES_CONNECTION,
+// создает в ней схему игр с данными и индексы наград. Транзакция откатывается
+// в конце теста, поэтому база должна быть пустой. Без TEST_POSTGRES_CONNECTION
+// тест пропускается.
+func newFixtureDB(t *testing.T) (*DB, *queryRecorder, pgx.Tx) {
+	t.Helper()
+	dsn := os.Getenv("TEST_POSTGRES_CONNECTION")
+	if dsn == "" {
+		t.Skip("TEST_POSTGRES_CONNECTION is not set")
+	}
+	ctx := context.Background()
+
+	cfg, err := pgxpool.ParseConfig(dsn)
+	if err != nil {
+		t.Fatalf("ParseConfig() error = %v", err)
+	}
+	recorder := &queryRecorder{}
+	cfg.ConnConfig.Tracer = recorder
+	pool, err := pgxpool.NewWithConfig(ctx, cfg)
+	if err != nil {
+		t.Fatalf("NewWithConfig() error = %v", err)
+	}
+	t.Cleanup(pool.Close)
+
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		t.Fatalf("Begin() error = %v", err)
+	}
+	t.Cleanup(func() { tx.Rollback(ctx) })
+
+	if _, err := tx.Exec(ctx, fixtures); err != nil {
+		t.Fatalf("failed to create fixtures, the test database must not contain game or account tables: %v", err)
+	}
+	indexes, err := os.ReadFile("../../migrations/20261018150000_add_award_query_indexes.up.sql")
+	if err != nil {
+		t.Fatalf("failed to read index migration: %v", err)
+	}
+	if _, err := tx.Exec(ctx, string(indexes)); err != nil {
+		t.Fatalf("failed to apply index migration: %v", err)
+	}
+
+	return &DB{Conn: pool, tx: tx}, recorder, tx
+}
+
+// newStatisticDB открывает тестовую базу, как newFixtureDB, и применяет к ней
+// все миграции, чтобы тест мог сохранять и читать награды.
+func newStatisticDB(t *testing.T) *DB {
+	t.Helper()
+	db, _, tx := newFixtureDB(t)
+	ctx := context.Background()
+
+	migrations, err := filepath.Glob("../../migrations/*.up.sql")
+	if err != nil {
+		t.Fatalf("failed to list migrations: %v", err)
+	}
+	for _, m := range migrations {
+		sql, err := os.ReadFile(m)
+		if err != nil {
+			t.Fatalf("failed to read migration: %v", err)
+		}
+		if _, err := tx.Exec(ctx, string(sql)); err != nil {
+			t.Fatalf("failed to apply migration %s: %v", filepath.Base(m), err)
+		}
+	}
+	return db
+}
+
+// TestQueriesUseIndexes проверяет по EXPLAIN, что запросы наград выбирают игры
+// периода по индексам, а не последовательным сканированием game.game и game.team.
+func TestQueriesUseIndexes(t *testing.T) {
+	db, recorder, tx := newFixtureDB(t)
+	ctx := context.Background()
+
+	period := MonthPeriod(2024, time.June)
+	queries := []struct {
+		name string
+		run  func() error
+	}{
+		{"FinalRatings", func() error { _, err := db.FinalRatings(ctx, period, BEST_PLAYER_BY_RATING_MONTH); return err }},
+		{"Winrates", func() error { _, err := db.Winrates(ctx, period, TOP_WINRATE_MONTH); return err }},
+		{"RatingChanges", func() error { _, err := db.RatingChanges(ctx, period, TOP_GAINED_RATING_MONTH); return err }},
+		{"MaxGamesPlayed", func() error { _, err := db.MaxGamesPlayed(ctx, period, MAX_GAMES_PLAYED_MONTH); return err }},
+		{"LongestWinStreak", func() error { _, err := db.LongestWinStreak(ctx, period, LONGEST_WIN_STREAK_MONTH); return err }},
+		{"LongestLossStreak", func() error { _, err := db.LongestLossStreak(ctx, period, LONGEST_LOSS_STREAK_MONTH); return err }},
+		{"LongestUnbeatenRun", func() error { _, err := db.LongestUnbeatenRun(ctx, period, LONGEST_UNBEATEN_RUN_MONTH); return err }},
+		// CurrentWinStreak не проверяется: текущая серия прослеживается по всем
+		// прошлым играм игроков периода, а не только по играм периода
+	}
+	indexed := map[string]bool{"game": true, "team": true}
+
+	for _, tt := range queries {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder.take()
+			if err := tt.run(); err != nil {
+				t.Fatalf("%s() error = %v", tt.name, err)
+			}
+
+			for _, q := range recorder.take() {
+				var plan []map[string]any
+				if err := tx.QueryRow(ctx, "EXPLAIN (FORMAT JSON) "+q.sql, q.args...).Scan(&plan); err != nil {
+					t.Fatalf("EXPLAIN error = %v", err)
+				}
+				root, _ := plan[0]["Plan"].(map[string]any)
+				if scans := seqScans(root, indexed); len(scans) > 0 {
+					t.Errorf("query uses sequential scan on %v:\n%s", scans, q.sql)
+				}
+			}
+		})
+	}
+}
+
+// TestQueriesShareGameTime проверяет, что все награды относят к периоду одни и те
+// же игры: по времени окончания и без незавершенных игр.
+func TestQueriesShareGameTime(t *testing.T) {
+	db, _, _ := newFixtureDB(t)
+	ctx := context.Background()
+	period := MonthPeriod(2024, time.June)
+
+	played, err := db.MaxGamesPlayed(ctx, period, MAX_GAMES_PLAYED_MONTH)
+	if err != nil {
+		t.Fatalf("MaxGamesPlayed() error = %v", err)
+	}
+	want := make(map[int]int, len(played))
+	for _, c := range played {
+		if c.RewardType == MAX_GAMES_PLAYED_MONTH {
+			want[c.UserID] = c.Games
+		}
+	}
+
+	queries := []struct {
+		name       string
+		rewardType string
+		run        func(context.Context, Period, string) ([]Candidate, error)
+	}{
+		{"Winrates", TOP_WINRATE_MONTH, db.Winrates},
+		{"RatingChanges", TOP_GAINED_RATING_MONTH, db.RatingChanges},
+		{"LongestWinStreak", LONGEST_WIN_STREAK_MONTH, db.LongestWinStreak},
+	}
+	for _, tt := range queries {
+		t.Run(tt.name, func(t *testing.T) {
+			candidates, err := tt.run(ctx, period, tt.rewardType)
+			if err != nil {
+				t.Fatalf("%s() error = %v", tt.name, err)
+			}
+			if len(candidates) == 0 {
+				t.Fatalf("%s() returned no candidates", tt.name)
+			}
+			var overall int
+			for _, c := range candidates {
+				if c.RewardType != tt.rewardType {
+					continue
+				}
+				overall++
+				if c.Games != want[c.UserID] {
+					t.Errorf("%s() user %d games = %d, want %d", tt.name, c.UserID, c.Games, want[c.UserID])
+				}
+				if c.AchievedAt.Before(period.Start) || !c.AchievedAt.Before(period.End) {
+					t.Errorf("%s() user %d achieved at %v, outside of %s", tt.name, c.UserID, c.AchievedAt, period)
+				}
+			}
+			if overall == 0 {
+				t.Errorf("%s() returned no %s candidates", tt.name, tt.rewardType)
+			}
+		})
+	}
+}
+
+// TestQueriesByGameType проверяет, что игры по форматам в сумме дают все игры игрока.
+func TestQueriesByGameType(t *testing.T) {
+	db, _, _ := newFixtureDB(t)
+	ctx := context.Background()
+	period := MonthPeriod(2024, time.June)
+
+	gameTypes, err := db.GameTypes(ctx, period)
+	if err != nil {
+		t.Fatalf("GameTypes() error = %v", err)
+	}
+	if want := []string{"1x1", "2x2", "3x3", "4x4", "5x5"}; !reflect.DeepEqual(gameTypes, want) {
+		t.Fatalf("GameTypes() = %v, want %v", gameTypes, want)
+	}
+
+	played, err := db.MaxGamesPlayed(ctx, period, MAX_GAMES_PLAYED_MONTH)
+	if err != nil {
+		t.Fatalf("MaxGamesPlayed() error = %v", err)
+	}
+	total := make(map[int]int)
+	byType := make(map[int]int)
+	for _, c := range played {
+		if c.RewardType == MAX_GAMES_PLAYED_MONTH {
+			total[c.UserID] = c.Games
+		} else {
+			byType[c.UserID] += c.Games
+		}
+	}
+	if len(total) == 0 {
+		t.Fatal("MaxGamesPlayed() returned no overall candidates")
+	}
+	if !reflect.DeepEqual(byType, total) {
+		t.Errorf("MaxGamesPlayed() games by type = %v, want %v", byType, total)
+	}
+}
+
+func TestGameTypeReward(t *testing.T) {
+	tests := []struct {
+		name       string
+		rewardType string
+		gameType   string
+		want       string
+	}{
+		{"all games", TOP_WINRATE_MONTH, "", "top_winrate"},
+		{"game type", TOP_WINRATE_MONTH, "3x3", "top_winrate_3x3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GameTypeReward(tt.rewardType, tt.gameType); got != tt.want {
+				t.Errorf("GameTypeReward() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestRatingNewGameType проверяет, что для нового формата игры награды за рейтинг
+// появляются без изменения кода.
+func TestRatingNewGameType(t *testing.T) {
+	db, _, tx := newFixtureDB(t)
+	ctx := context.Background()
+	period := MonthPeriod(2024, time.June)
+
+	_, err := tx.Exec(ctx, `
+        WITH g AS (
+            INSERT INTO game.game (type, end_time) VALUES ('21', timestamptz '2024-06-10 18:00:00+00') RETURNING id, end_time
+        ), t AS (
+            INSERT INTO game.team (game_id, is_winner, created_at) SELECT id, true, end_time FROM g RETURNING id
+        )
+        INSERT INTO game.team_members (team_id, user_id, new_rating, changed_rating) SELECT id, 1, 1500, 10 FROM t;
+    `)
+	if err != nil {
+		t.Fatalf("failed to insert game: %v", err)
+	}
+
+	for _, tt := range []struct {
+		rewardType string
+		want       string
+	}{
+		{BEST_PLAYER_BY_RATING_MONTH, "best_rating_21"},
+		{WORST_PLAYER_BY_RATING_MONTH, "worst_rating_21"},
+	} {
+		t.Run(tt.rewardType, func(t *testing.T) {
+			candidates, err := db.FinalRatings(ctx, period, tt.rewardType)
+			if err != nil {
+				t.Fatalf("FinalRatings() error = %v", err)
+			}
+			var got []Candidate
+			for _, c := range candidates {
+				if c.RewardType == tt.want {
+					got = append(got, c)
+				}
+			}
+			if len(got) != 1 || got[0].UserID != 1 || got[0].Value != 1500 {
+				t.Errorf("FinalRatings() %q candidates = %+v, want user 1 with rating 1500", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRewardTypeTitle(t *testing.T) {
+	tests := []struct {
+		name string
+		code string
+		want string
+	}{
+		{"base award", TOP_WINRATE_MONTH, "Лучший процент побед!"},
+		{"game type", "top_winrate_3x3", "Лучший процент побед 3x3!"},
+		{"rating game type", "best_rating_1x1", "Лучший игрок по рейтингу 1x1!"},
+		{"game type with underscore", "max_games_played_3x3_half", "Наибольшее количество сыгранных игр 3x3_half!"},
+		{"unknown code", "mvp", "mvp"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RewardTypeTitle(tt.code); got != tt.want {
+				t.Errorf("RewardTypeTitle() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestQueriesEvidence проверяет, что кандидаты содержат игры, подтверждающие показатель.
+func TestQueriesEvidence(t *testing.T) {
+	db, _, _ := newFixtureDB(t)
+	ctx := context.Background()
+	period := MonthPeriod(2024, time.June)
+
+	tests := []struct {
+		name       string
+		run        func(context.Context, Period, string) ([]Candidate, error)
+		rewardType string
+		want       func(c Candidate) int
+	}{
+		{"FinalRatings", db.FinalRatings, BEST_PLAYER_BY_RATING_MONTH, func(Candidate) int { return 1 }},
+		{"Winrates", db.Winrates, TOP_WINRATE_MONTH, func(c Candidate) int { return c.Games }},
+		{"RatingChanges", db.RatingChanges, TOP_GAINED_RATING_MONTH, func(c Candidate) int { return c.Games }},
+		{"MaxGamesPlayed", db.MaxGamesPlayed, MAX_GAMES_PLAYED_MONTH, func(c Candidate) int { return c.Games }},
+		{"LongestWinStreak", db.LongestWinStreak, LONGEST_WIN_STREAK_MONTH, func(c Candidate) int { return int(c.Value) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			candidates, err := tt.run(ctx, period, tt.rewardType)
+			if err != nil {
+				t.Fatalf("%s() error = %v", tt.name, err)
+			}
+			if len(candidates) == 0 {
+				t.Fatalf("%s() returned no candidates", tt.name)
+			}
+			for _, c := range candidates {
+				if len(c.GameIDs) != tt.want(c) {
+					t.Errorf("%s() user %d %s has %d evidence games, want %d", tt.name, c.UserID, c.RewardType, len(c.GameIDs), tt.want(c))
+				}
+			}
+		})
+	}
+}
+
+// TestClearRewards проверяет, что пересчет награды удаляет прежних победителей
+// всех ее форматов игры и не трогает другие награды.
+func TestClearRewards(t *testing.T) {
+	db := newStatisticDB(t)
+	ctx := context.Background()
+	period := MonthPeriod(2024, time.June)
+
+	for _, code := range []string{TOP_WINRATE_MONTH, "top_winrate_1x1", "best_rating_1x1"} {
+		if err := db.EnsureRewardType(ctx, code); err != nil {
+			t.Fatalf("EnsureRewardType() error = %v", err)
+		}
+		if _, err := db.SaveReward(ctx, period, Winner{UserID: 1, RewardType: code, Rank: 1, Value: 1}); err != nil {
+			t.Fatalf("SaveReward() error = %v", err)
+		}
+	}
+
+	if err := db.ClearRewards(ctx, period, TOP_WINRATE_MONTH); err != nil {
+		t.Fatalf("ClearRewards() error = %v", err)
+	}
+
+	rewards, err := db.RewardsByPeriod(ctx, period, DefaultLocale)
+	if err != nil {
+		t.Fatalf("RewardsByPeriod() error = %v", err)
+	}
+	var got []string
+	for _, r := range rewards {
+		got = append(got, r.TypeCode)
+	}
+	if want := []string{"best_rating_1x1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("rewards after ClearRewards() = %v, want %v", got, want)
+	}
+}
+
+// TestRewardTypeCodesDistinct проверяет, что код одной награды не начинается
+// с кода другой: ClearRewards и RewardTypeTitle находят варианты награды по
+// формату игры по префиксу кода.
+func TestRewardTypeCodesDistinct(t *testing.T) {
+	for a := range rewardTypeTitles {
+		for b := range rewardTypeTitles {
+			if a != b && strings.HasPrefix(b, a+"_") {
+				t.Errorf("reward type %q looks like a game type variant of %q", b, a)
+			}
+		}
+	}
+}
+
+// TestHasRewards проверяет, что сохраненные победители одной награды не
+// делают посчитанными другие награды периода.
+func TestHasRewards(t *testing.T) {
+	db := newStatisticDB(t)
+	ctx := context.Background()
+	period := MonthPeriod(2024, time.June)
+
+	if err := db.EnsureRewardType(ctx, "top_winrate_1x1"); err != nil {
+		t.Fatalf("EnsureRewardType() error = %v", err)
+	}
+	if _, err := db.SaveReward(ctx, period, Winner{UserID: 1, RewardType: "top_winrate_1x1", Rank: 1, Value: 1}); err != nil {
+		t.Fatalf("SaveReward() error = %v", err)
 	}
+
+	tests := []struct {
+		rewardType string
+		want       bool
+	}{
+		{TOP_WINRATE_MONTH, true},
+		{MAX_LOSERATE_MONTH, false},
+		{BEST_PLAYER_BY_RATING_MONTH, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.rewardType, func(t *testing.T) {
+			got, err := db.HasRewards(ctx, period, tt.rewardType)
+			if err != nil {
+				t.Fatalf("HasRewards() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("HasRewards() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestRewardTypeTranslations проверяет, что награды по формату игры получают
+// переводы базовой награды, в том числе награды за рейтинг, базовый тип
+// которых никогда не сохраняется.
+func TestRewardTypeTranslations(t *testing.T) {
+	db := newStatisticDB(t)
+	ctx := context.Background()
+
+	if err := db.EnsureRewardType(ctx, "best_rating_6x6"); err != nil {
+		t.Fatalf("EnsureRewardType() error = %v", err)
+	}
+	types, err := db.RewardTypes(ctx, "en")
+	if err != nil {
+		t.Fatalf("RewardTypes() error = %v", err)
+	}
+	titles := make(map[string]string, len(types))
+	for _, rt := range types {
+		titles[rt.Code] = rt.Type
+	}
+
+	tests := []struct {
+		code string
+		want string
+	}{
+		{"best_rating_1x1", "Best player by rating 1x1!"},
+		{"worst_rating_5x5", "Worst player by rating 5x5!"},
+		{"best_rating_6x6", "Best player by rating 6x6!"},
+		{TOP_WINRATE_MONTH, "Best win rate!"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			if got := titles[tt.code]; got != tt.want {
+				t.Errorf("RewardTypes() title = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestCurrentWinStreak проверяет, что текущая серия побед учитывает игры до
+// начала периода, если серия началась раньше.
+func TestCurrentWinStreak(t *testing.T) {
+	db, _, tx := newFixtureDB(t)
+	ctx := context.Background()
+
+	var userID int
+	err := tx.QueryRow(ctx, `
+        WITH u AS (
+            INSERT INTO account."user" (first_name, last_name) VALUES ('streak', 'player') RETURNING id
+        ), g AS (
+            INSERT INTO game.game (type, end_time)
+            SELECT '1x1', end_time
+            FROM unnest(ARRAY['2024-05-30 20:00+00', '2024-05-31 20:00+00', '2024-06-01 10:00+00', '2024-06-02 10:00+00']::timestamptz[]) end_time
+            RETURNING id, end_time
+        ), t AS (
+            INSERT INTO game.team (game_id, is_winner, created_at)
+            SELECT id, end_time <> '2024-05-30 20:00+00', end_time - interval '30 minutes' FROM g
+            RETURNING id
+        ), o AS (
+            -- Победившая команда соперника в первой игре
+            INSERT INTO game.team (game_id, is_winner, created_at)
+            SELECT id, true, end_time - interval '30 minutes' FROM g WHERE end_time = '2024-05-30 20:00+00'
+        )
+        INSERT INTO game.team_members (team_id, user_id, new_rating, changed_rating)
+        SELECT t.id, u.id, 1000, 10 FROM t, u
+        RETURNING user_id
+    `).Scan(&userID)
+	if err != nil {
+		t.Fatalf("failed to create streak games: %v", err)
+	}
+
+	period := MonthPeriod(2024, time.June)
+	candidates, err := db.CurrentWinStreak(ctx, period, CURRENT_WIN_STREAK)
+	if err != nil {
+		t.Fatalf("CurrentWinStreak() error = %v", err)
+	}
+	for _, c := range candidates {
+		if c.UserID != userID || c.RewardType != CURRENT_WIN_STREAK {
+			continue
+		}
+		if c.Value != 3 || len(c.GameIDs) != 3 || c.Games != 2 {
+			t.Errorf("CurrentWinStreak() = %v wins in %d games, evidence %v, want 3 wins in 2 games", c.Value, c.Games, c.GameIDs)
+		}
+		if want := time.Date(2024, time.May, 31, 20, 0, 0, 0, time.UTC); !c.StartedAt.Equal(want) {
+			t.Errorf("CurrentWinStreak() started at %v, want %v", c.StartedAt, want)
+		}
+		return
+	}
+	t.Fatalf("CurrentWinStreak() has no candidate for user %d", userID)
+}
+
+// TestInRollbackTx проверяет, что InRollbackTx откатывает всё, что сделано
+// внутри: личные рекорды серий обновлены в транзакции, после нее таблицы не
+// изменились.
+func TestInRollbackTx(t *testing.T) {
+	db := newStatisticDB(t)
+	ctx := context.Background()
+
+	err := db.InRollbackTx(ctx, func(tx *DB) error {
+		if err := tx.UpdateStreakRecords(ctx, MonthPeriod(2024, time.June).End); err != nil {
+			return err
+		}
+		records, err := tx.StreakRecords(ctx, StreakWins, "")
+		if err != nil {
+			return err
+		}
+		if len(records) == 0 {
+			t.Error("UpdateStreakRecords() saved no records inside the transaction")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("InRollbackTx() error = %v", err)
+	}
+
+	var records, states, lastGame int
+	err = db.querier().QueryRow(ctx, `
+        SELECT
+            (SELECT COUNT(*) FROM statistic.streak_record),
+            (SELECT COUNT(*) FROM statistic.streak_state),
+            (SELECT game_id FROM statistic.streak_progress)
+    `).Scan(&records, &states, &lastGame)
+	if err != nil {
+		t.Fatalf("failed to read streak tables: %v", err)
+	}
+	if records != 0 || states != 0 || lastGame != 0 {
+		t.Errorf("InRollbackTx() left %d records, %d states and progress at game %d, want none", records, states, lastGame)
+	}
+}
+
+// TestWinStreakRecord проверяет, что награда достается только за новый рекорд
+// лиги: нужен рекорд до периода, и из побивших его награждается самая длинная
+// серия периода.
+func TestWinStreakRecord(t *testing.T) {
+	db := newStatisticDB(t)
+	ctx := context.Background()
+	period := MonthPeriod(2024, time.June)
+
+	type record struct {
+		userID     int
+		length     int
+		achievedAt time.Time
+	}
+	may := time.Date(2024, time.May, 20, 12, 0, 0, 0, time.UTC)
+	june := time.Date(2024, time.June, 10, 12, 0, 0, 0, time.UTC)
+
 	tests := []struct {
 		name    string
-		args    args
-		want    *DB
-		wantErr bool
+		records []record
+		want    map[int]float64
 	}{
-		// TODO: Add test cases.
+		{
+			name:    "no earlier record",
+			records: []record{{1, 3, june}, {2, 5, june}},
+			want:    map[int]float64{},
+		},
+		{
+			name: "earlier record",
+			records: []record{
+				{3, 4, may},
+				{1, 3, june},
+				{2, 5, june},
+				{4, 5, june},
+				{4, 6, june.Add(time.Hour)},
+			},
+			want: map[int]float64{4: 6},
+		},
+		{
+			name:    "earlier record not broken",
+			records: []record{{3, 4, may}, {1, 4, june}},
+			want:    map[int]float64{},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := NewDB(tt.args.url)
-			if (err != nil) != tt.wantErr {
-				t.Errorf("NewDB() error = %v, wantErr %v", err, tt.wantErr)
-				return
+			err := db.InRollbackTx(ctx, func(tx *DB) error {
+				for _, r := range tt.records {
+					_, err := tx.querier().Exec(ctx, `
+                        INSERT INTO statistic.streak_record (user_id, kind, game_type, length, started_at, achieved_at, game_ids)
+                        VALUES ($1, 'wins', '', $2, $3, $3, '{}')
+                    `, r.userID, r.length, r.achievedAt)
+					if err != nil {
+						return err
+					}
+				}
+
+				candidates, err := tx.WinStreakRecord(ctx, period, WIN_STREAK_RECORD)
+				if err != nil {
+					return err
+				}
+				got := make(map[int]float64)
+				for _, c := range candidates {
+					if c.RewardType == WIN_STREAK_RECORD {
+						got[c.UserID] = c.Value
+					}
+				}
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("WinStreakRecord() = %v, want %v", got, tt.want)
+				}
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("WinStreakRecord() error = %v", err)
+			}
+		})
+	}
+}
+
+// TestLeaderboard проверяет, что медали считаются только за награды выбранной
+// длительности и антинаграды их не приносят.
+func TestLeaderboard(t *testing.T) {
+	db := newStatisticDB(t)
+	ctx := context.Background()
+
+	month := MonthPeriod(2024, time.June)
+	week, err := NewPeriod(GranularityWeek, month.Start)
+	if err != nil {
+		t.Fatalf("NewPeriod() error = %v", err)
+	}
+	rewards := []struct {
+		period Period
+		winner Winner
+	}{
+		{month, Winner{UserID: 1, RewardType: "best_rating_1x1", Rank: 1, Value: 1500}},
+		{month, Winner{UserID: 2, RewardType: "worst_rating_1x1", Rank: 1, Value: 900}},
+		{month, Winner{UserID: 2, RewardType: MAX_LOSERATE_MONTH, Rank: 1, Value: 0.1}},
+		{week, Winner{UserID: 3, RewardType: TOP_WINRATE_MONTH, Rank: 1, Value: 1}},
+	}
+	for _, r := range rewards {
+		if err := db.EnsureRewardType(ctx, r.winner.RewardType); err != nil {
+			t.Fatalf("EnsureRewardType() error = %v", err)
+		}
+		if _, err := db.SaveReward(ctx, r.period, r.winner); err != nil {
+			t.Fatalf("SaveReward() error = %v", err)
+		}
+	}
+
+	tests := []struct {
+		granularity Granularity
+		want        map[int]int
+	}{
+		{GranularityMonth, map[int]int{1: 1}},
+		{GranularityWeek, map[int]int{3: 1}},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.granularity), func(t *testing.T) {
+			entries, err := db.Leaderboard(ctx, nil, tt.granularity)
+			if err != nil {
+				t.Fatalf("Leaderboard() error = %v", err)
+			}
+			got := make(map[int]int)
+			for _, e := range entries {
+				got[e.UserID] = e.Gold
+				if e.Total != e.Gold+e.Silver+e.Bronze {
+					t.Errorf("Leaderboard() user %d total = %d, want medals only", e.UserID, e.Total)
+				}
 			}
 			if !reflect.DeepEqual(got, tt.want) {
-				t.Errorf("NewDB() got = %v, want %v", got, tt.want)
+				t.Errorf("Leaderboard() gold = %v, want %v", got, tt.want)
 			}
 		})
 	}
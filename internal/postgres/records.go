@@ -0,0 +1,436 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Личные рекорды серий ведутся за всё время, поэтому серии продолжаются через
+// границы месяцев. Таблицы обновляются инкрементально: каждая игра учитывается
+// один раз, в порядке окончания, начиная с последней учтенной игры из
+// statistic.streak_progress.
+
+const (
+	QueryStreakProgress = `
+        SELECT end_time, game_id FROM statistic.streak_progress FOR UPDATE;
+    `
+
+	QueryUpdateStreakProgress = `
+        UPDATE statistic.streak_progress SET end_time = $1, game_id = $2;
+    `
+
+	// QueryStreakGames выбирает игры, закончившиеся после игры $3 со временем
+	// окончания $1 и до $2, в порядке окончания.
+	QueryStreakGames = `
+        WITH ` + QueryPeriodGames + `
+        SELECT
+            pg.user_id,
+            pg.type,
+            pg.game_id,
+            pg.is_winner,
+            EXISTS (
+                SELECT 1 FROM game.team o
+                WHERE o.game_id = pg.game_id AND o.id <> pg.team_id AND o.is_winner
+            ) AS lost,
+            pg.end_time
+        FROM
+            period_games pg
+        WHERE
+            (pg.end_time, pg.game_id) > ($1, $3)
+        ORDER BY
+            pg.end_time ASC, pg.game_id ASC;
+    `
+
+	QueryStreakStates = `
+        SELECT user_id, kind, game_type, started_at, ended_at, game_ids FROM statistic.streak_state;
+    `
+
+	// QueryDeleteStreakStates удаляет закончившиеся серии с ключами из
+	// массивов $1, $2 и $3.
+	QueryDeleteStreakStates = `
+        DELETE FROM statistic.streak_state s
+        USING unnest($1::int[], $2::text[], $3::text[]) AS e(user_id, kind, game_type)
+        WHERE s.user_id = e.user_id AND s.kind = e.kind AND s.game_type = e.game_type;
+    `
+
+	QueryUpsertStreakState = `
+        INSERT INTO statistic.streak_state (user_id, kind, game_type, started_at, ended_at, game_ids)
+        VALUES ($1, $2, $3, $4, $5, $6)
+        ON CONFLICT (user_id, kind, game_type) DO UPDATE SET
+            started_at = EXCLUDED.started_at,
+            ended_at = EXCLUDED.ended_at,
+            game_ids = EXCLUDED.game_ids;
+    `
+
+	QueryStreakBests = `
+        SELECT user_id, kind, game_type, MAX(length)
+        FROM statistic.streak_record
+        GROUP BY user_id, kind, game_type;
+    `
+
+	QueryInsertStreakRecord = `
+        INSERT INTO statistic.streak_record (user_id, kind, game_type, length, started_at, achieved_at, game_ids)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
+        ON CONFLICT (user_id, kind, game_type, length) DO NOTHING;
+    `
+
+	// QueryStreakRecords выбирает лучший личный рекорд каждого игрока для серий
+	// вида $1 по формату игры $2, от самой длинной серии.
+	QueryStreakRecords = `
+        SELECT
+            r.user_id,
+            u.first_name,
+            u.last_name,
+            COALESCE(u.number::text, ''),
+            COALESCE(u.icon::text, ''),
+            r.kind,
+            r.game_type,
+            r.length,
+            r.started_at,
+            r.achieved_at,
+            r.game_ids
+        FROM (
+            SELECT DISTINCT ON (user_id) *
+            FROM statistic.streak_record
+            WHERE kind = $1 AND game_type = $2
+            ORDER BY user_id, length DESC
+        ) r
+        JOIN
+            account.user u ON u.id = r.user_id
+        ORDER BY
+            r.length DESC, r.achieved_at, r.user_id;
+    `
+
+	// QueryStreakRecordsBroken выбирает игроков, чья серия вида $4 по формату
+	// игры $3 установила в периоде [$1, $2) новый рекорд лиги: превысила рекорд,
+	// установленный до периода, и стала самой длинной в периоде. Без рекорда до
+	// периода побивать нечего, поэтому первый период и новый формат игры
+	// наград не дают. Для каждого игрока берется самая ранняя такая серия.
+	QueryStreakRecordsBroken = `
+        WITH previous AS (
+            SELECT MAX(length) AS length
+            FROM statistic.streak_record
+            WHERE kind = $4 AND game_type = $3 AND achieved_at < $1
+        ),
+        broken AS (
+            SELECT r.*
+            FROM
+                statistic.streak_record r
+            CROSS JOIN
+                previous p
+            WHERE
+                r.kind = $4 AND r.game_type = $3
+                AND r.achieved_at >= $1 AND r.achieved_at < $2
+                AND r.length > p.length
+        )
+        SELECT DISTINCT ON (user_id)
+            user_id,
+            length::FLOAT AS value,
+            started_at,
+            achieved_at,
+            game_ids
+        FROM
+            broken
+        WHERE
+            length = (SELECT MAX(length) FROM broken)
+        ORDER BY
+            user_id, achieved_at;
+    `
+)
+
+// streakKey — серия игрока одного вида по формату игры. GameType пустой для
+// серий по всем играм.
+type streakKey struct {
+	UserID   int
+	Kind     StreakKind
+	GameType string
+}
+
+// runningStreak — продолжающаяся серия игрока. Пустая серия не хранится.
+type runningStreak struct {
+	StartedAt time.Time
+	EndedAt   time.Time
+	GameIDs   []int
+}
+
+// streakRecord — новый личный рекорд: серия впервые достигла длины len(GameIDs).
+type streakRecord struct {
+	streakKey
+	runningStreak
+}
+
+// playerGame — результат игры игрока вместе с форматом игры.
+type playerGame struct {
+	UserID   int
+	GameType string
+	GameResult
+}
+
+// advanceStreaks продолжает серии running играми games, отсортированными по
+// времени, и возвращает новые личные рекорды в порядке их установки. Серии
+// ведутся по всем играм и отдельно по формату каждой игры. best — длины
+// личных рекордов, обновляются вместе с running. В changed отмечаются ключи
+// серий, которые продолжились или закончились.
+func advanceStreaks(running map[streakKey]runningStreak, best map[streakKey]int, changed map[streakKey]bool, games []playerGame) []streakRecord {
+	var records []streakRecord
+	for _, g := range games {
+		gameTypes := []string{""}
+		if g.GameType != "" {
+			gameTypes = append(gameTypes, g.GameType)
+		}
+		for _, kind := range streakKinds {
+			for _, gameType := range gameTypes {
+				key := streakKey{UserID: g.UserID, Kind: kind, GameType: gameType}
+				if !kind.continues(g.GameResult) {
+					if _, ok := running[key]; ok {
+						delete(running, key)
+						changed[key] = true
+					}
+					continue
+				}
+
+				s := running[key]
+				if len(s.GameIDs) == 0 {
+					s.StartedAt = g.Time
+				}
+				s.EndedAt = g.Time
+				s.GameIDs = append(s.GameIDs, g.GameID)
+				running[key] = s
+				changed[key] = true
+
+				if len(s.GameIDs) > best[key] {
+					best[key] = len(s.GameIDs)
+					records = append(records, streakRecord{streakKey: key, runningStreak: s})
+				}
+			}
+		}
+	}
+	return records
+}
+
+// UpdateStreakRecords учитывает в личных рекордах серий игры, закончившиеся
+// после последней учтенной игры и до through. Игры, которым время окончания
+// проставлено задним числом раньше уже учтенных, в рекорды не попадают.
+// Обновление выполняется в своей короткой транзакции: строка
+// statistic.streak_progress блокируется только на время обновления, а
+// сохраняются только изменившиеся серии, одним пакетом запросов.
+func (conn *DB) UpdateStreakRecords(ctx context.Context, through time.Time) error {
+	return conn.InTx(ctx, func(tx *DB) error {
+		var (
+			lastEnd  time.Time
+			lastGame int
+		)
+		if err := tx.querier().QueryRow(ctx, QueryStreakProgress).Scan(&lastEnd, &lastGame); err != nil {
+			return fmt.Errorf("failed to read streak progress: %w", err)
+		}
+		if !through.After(lastEnd) {
+			return nil
+		}
+
+		games, err := tx.streakGames(ctx, lastEnd, through, lastGame)
+		if err != nil {
+			return err
+		}
+		if len(games) == 0 {
+			return nil
+		}
+		running, err := tx.runningStreaks(ctx)
+		if err != nil {
+			return err
+		}
+		best, err := tx.streakBests(ctx)
+		if err != nil {
+			return err
+		}
+
+		stored := make(map[streakKey]bool, len(running))
+		for key := range running {
+			stored[key] = true
+		}
+		changed := make(map[streakKey]bool)
+		records := advanceStreaks(running, best, changed, games)
+
+		batch := &pgx.Batch{}
+		for _, r := range records {
+			batch.Queue(QueryInsertStreakRecord,
+				r.UserID, r.Kind.String(), r.GameType, len(r.GameIDs), r.StartedAt, r.EndedAt, r.GameIDs)
+		}
+		var (
+			endedUsers []int
+			endedKinds []string
+			endedTypes []string
+		)
+		for key := range changed {
+			if s, ok := running[key]; ok {
+				batch.Queue(QueryUpsertStreakState,
+					key.UserID, key.Kind.String(), key.GameType, s.StartedAt, s.EndedAt, s.GameIDs)
+			} else if stored[key] {
+				endedUsers = append(endedUsers, key.UserID)
+				endedKinds = append(endedKinds, key.Kind.String())
+				endedTypes = append(endedTypes, key.GameType)
+			}
+		}
+		if len(endedUsers) > 0 {
+			batch.Queue(QueryDeleteStreakStates, endedUsers, endedKinds, endedTypes)
+		}
+		last := games[len(games)-1]
+		batch.Queue(QueryUpdateStreakProgress, last.Time, last.GameID)
+
+		if err := tx.querier().SendBatch(ctx, batch).Close(); err != nil {
+			return fmt.Errorf("failed to save streak records: %w", err)
+		}
+		log.Printf("Streak records updated with %d game results, %d new records, %d changed streaks", len(games), len(records), len(changed))
+		return nil
+	})
+}
+
+// streakGames возвращает результаты игр, закончившихся после игры lastGame со
+// временем окончания lastEnd и до through, в порядке окончания.
+func (conn *DB) streakGames(ctx context.Context, lastEnd, through time.Time, lastGame int) ([]playerGame, error) {
+	rows, err := conn.querier().Query(ctx, QueryStreakGames, lastEnd, through, lastGame)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query streak games: %w", err)
+	}
+	defer rows.Close()
+
+	var games []playerGame
+	for rows.Next() {
+		var g playerGame
+		if err := rows.Scan(&g.UserID, &g.GameType, &g.GameID, &g.Won, &g.Lost, &g.Time); err != nil {
+			return nil, fmt.Errorf("failed to scan streak game: %w", err)
+		}
+		games = append(games, g)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read streak games: %w", err)
+	}
+	return games, nil
+}
+
+// runningStreaks возвращает сохраненные текущие серии игроков.
+func (conn *DB) runningStreaks(ctx context.Context) (map[streakKey]runningStreak, error) {
+	rows, err := conn.querier().Query(ctx, QueryStreakStates)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query streak states: %w", err)
+	}
+	defer rows.Close()
+
+	running := make(map[streakKey]runningStreak)
+	for rows.Next() {
+		var (
+			key  streakKey
+			kind string
+			s    runningStreak
+		)
+		if err := rows.Scan(&key.UserID, &kind, &key.GameType, &s.StartedAt, &s.EndedAt, &s.GameIDs); err != nil {
+			return nil, fmt.Errorf("failed to scan streak state: %w", err)
+		}
+		if key.Kind, err = ParseStreakKind(kind); err != nil {
+			return nil, err
+		}
+		running[key] = s
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read streak states: %w", err)
+	}
+	return running, nil
+}
+
+// streakBests возвращает длины личных рекордов серий.
+func (conn *DB) streakBests(ctx context.Context) (map[streakKey]int, error) {
+	rows, err := conn.querier().Query(ctx, QueryStreakBests)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query streak records: %w", err)
+	}
+	defer rows.Close()
+
+	best := make(map[streakKey]int)
+	for rows.Next() {
+		var (
+			key    streakKey
+			kind   string
+			length int
+		)
+		if err := rows.Scan(&key.UserID, &kind, &key.GameType, &length); err != nil {
+			return nil, fmt.Errorf("failed to scan streak record: %w", err)
+		}
+		if key.Kind, err = ParseStreakKind(kind); err != nil {
+			return nil, err
+		}
+		best[key] = length
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read streak records: %w", err)
+	}
+	return best, nil
+}
+
+// StreakRecords возвращает лучший личный рекорд серий вида kind каждого игрока
+// за всё время, от самой длинной серии. Пустой gameType — серии по всем играм.
+// Рекорды учитывают игры, обработанные последним UpdateStreakRecords.
+func (db *DB) StreakRecords(ctx context.Context, kind StreakKind, gameType string) ([]StreakRecord, error) {
+	rows, err := db.querier().Query(ctx, QueryStreakRecords, kind.String(), gameType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query streak records: %w", err)
+	}
+	defer rows.Close()
+
+	records := make([]StreakRecord, 0)
+	for rows.Next() {
+		var r StreakRecord
+		err := rows.Scan(
+			&r.UserID, &r.FirstName, &r.LastName, &r.Number, &r.Icon,
+			&r.Kind, &r.GameType, &r.Length, &r.StartedAt, &r.EndedAt, &r.GameIDs,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan streak record: %w", err)
+		}
+		records = append(records, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read streak records: %w", err)
+	}
+	return records, nil
+}
+
+// WinStreakRecord возвращает игроков, чья серия побед превысила в периоде
+// рекорд лиги, установленный до начала периода, по всем играм и отдельно по
+// каждому формату игры. Серия может начаться в одном из прошлых периодов.
+func (conn *DB) WinStreakRecord(ctx context.Context, period Period, rewardType string) ([]Candidate, error) {
+	return conn.recordsBroken(ctx, period, rewardType, StreakWins)
+}
+
+// UnbeatenRunRecord возвращает игроков, чья серия без поражений превысила в
+// периоде рекорд лиги, установленный до начала периода, по всем играм и
+// отдельно по каждому формату игры.
+func (conn *DB) UnbeatenRunRecord(ctx context.Context, period Period, rewardType string) ([]Candidate, error) {
+	return conn.recordsBroken(ctx, period, rewardType, StreakUnbeaten)
+}
+
+// recordsBroken выбирает игроков, побивших рекорд лиги для серий вида kind.
+// Личные рекорды должны быть обновлены по конец периода заранее, см.
+// UpdateStreakRecords: сам расчет награды ничего не сохраняет.
+func (conn *DB) recordsBroken(ctx context.Context, period Period, rewardType string, kind StreakKind) (candidates []Candidate, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("recovered from panic: %v", r)
+		}
+	}()
+
+	candidates, err = conn.byGameType(ctx, period, rewardType, true, func(gameType, rewardType string) ([]Candidate, error) {
+		return conn.queryCandidates(ctx, QueryStreakRecordsBroken, rewardType, period.Start, period.End, gameType, kind.String())
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find broken records: %w", err)
+	}
+
+	if len(candidates) == 0 {
+		log.Printf("No %s found for %s", rewardType, period)
+	}
+
+	return candidates, nil
+}
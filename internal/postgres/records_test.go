@@ -0,0 +1,95 @@
+package postgres
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAdvanceStreaks(t *testing.T) {
+	// Серия побед игрока 1 начинается в одном вызове и продолжается в следующем,
+	// как игры соседних месяцев
+	games := func(outcomes string, firstID int) []playerGame {
+		var played []playerGame
+		for i, g := range results(outcomes) {
+			g.GameID = firstID + i
+			played = append(played, playerGame{UserID: 1, GameType: "1x1", GameResult: g})
+		}
+		return played
+	}
+	winKey := streakKey{UserID: 1, Kind: StreakWins}
+
+	tests := []struct {
+		name        string
+		best        int
+		batches     []string
+		wantRecords [][]int
+		wantRunning []int
+		// wantChanged — изменилась ли серия побед в последнем вызове
+		wantChanged bool
+	}{
+		{
+			name:        "streak spans batches",
+			batches:     []string{"LWW", "WL"},
+			wantRecords: [][]int{{2}, {2, 3}, {2, 3, 4}},
+			wantRunning: nil,
+			wantChanged: true,
+		},
+		{
+			name:        "below previous best",
+			best:        3,
+			batches:     []string{"WW", "W", "W"},
+			wantRecords: [][]int{{1, 2, 3, 4}},
+			wantRunning: []int{1, 2, 3, 4},
+			wantChanged: true,
+		},
+		{
+			name:        "draw breaks wins",
+			best:        1,
+			batches:     []string{"WDW"},
+			wantRecords: nil,
+			wantRunning: []int{3},
+			wantChanged: true,
+		},
+		{
+			name:        "no streak to end",
+			batches:     []string{"L", "L"},
+			wantRecords: nil,
+			wantRunning: nil,
+			wantChanged: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			running := make(map[streakKey]runningStreak)
+			best := map[streakKey]int{winKey: tt.best}
+
+			var (
+				gotRecords [][]int
+				changed    map[streakKey]bool
+			)
+			nextID := 1
+			for _, batch := range tt.batches {
+				changed = make(map[streakKey]bool)
+				for _, r := range advanceStreaks(running, best, changed, games(batch, nextID)) {
+					if r.streakKey == winKey {
+						gotRecords = append(gotRecords, r.GameIDs)
+					}
+				}
+				nextID += len(batch)
+			}
+
+			if !reflect.DeepEqual(gotRecords, tt.wantRecords) {
+				t.Errorf("advanceStreaks() records = %v, want %v", gotRecords, tt.wantRecords)
+			}
+			if got := running[winKey].GameIDs; !reflect.DeepEqual(got, tt.wantRunning) {
+				t.Errorf("advanceStreaks() running = %v, want %v", got, tt.wantRunning)
+			}
+			if _, ok := running[streakKey{UserID: 1, Kind: StreakWins, GameType: "1x1"}]; ok != (tt.wantRunning != nil) {
+				t.Errorf("advanceStreaks() game type streak running = %v, want %v", ok, tt.wantRunning != nil)
+			}
+			if changed[winKey] != tt.wantChanged {
+				t.Errorf("advanceStreaks() changed = %v, want %v", changed[winKey], tt.wantChanged)
+			}
+		})
+	}
+}
@@ -0,0 +1,224 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	queryRewardColumns = `
+        SELECT
+            r.id,
+            r.user_id,
+            u.first_name,
+            u.last_name,
+            COALESCE(u.number::text, ''),
+            COALESCE(u.icon::text, ''),
+            rt.id,
+            rt.code,
+            COALESCE(tr.title, rt.type),
+            COALESCE(tr.description, ''),
+            r.rank,
+            COALESCE(r.value, 0)::FLOAT,
+            COALESCE(r.unit, ''),
+            r.details,
+            COALESCE((
+                SELECT array_agg(e.game_id ORDER BY e.position)
+                FROM statistic.reward_evidence e
+                WHERE e.reward_id = r.id
+            ), '{}'),
+            COALESCE(r.method, ''),
+            COALESCE(r.sample_size, 0),
+            r.period_start,
+            r.period_end,
+            r.granularity,
+            r.created_at
+        FROM
+            statistic.reward r
+        JOIN
+            statistic.reward_type rt ON rt.id = r.type
+        LEFT JOIN
+            statistic.reward_type_translation tr ON tr.code = rt.code AND tr.locale = $1
+        JOIN
+            account.user u ON u.id = r.user_id
+    `
+
+	QueryRewardsByPeriod = queryRewardColumns + `
+        WHERE r.period_start = $2 AND r.period_end = $3
+        ORDER BY rt.id, r.rank, r.user_id;
+    `
+
+	QueryRewardsByUser = queryRewardColumns + `
+        WHERE r.user_id = $2
+        ORDER BY r.period_start DESC, rt.id, r.rank;
+    `
+
+	QueryRewardTypes = `
+        SELECT
+            rt.id,
+            rt.code,
+            COALESCE(tr.title, rt.type),
+            COALESCE(tr.description, '')
+        FROM
+            statistic.reward_type rt
+        LEFT JOIN
+            statistic.reward_type_translation tr ON tr.code = rt.code AND tr.locale = $1
+        ORDER BY rt.id;
+    `
+
+	QueryLeaderboard = `
+        SELECT
+            u.id,
+            u.first_name,
+            u.last_name,
+            COALESCE(u.number::text, ''),
+            COALESCE(u.icon::text, ''),
+            COUNT(*) FILTER (WHERE r.rank = 1) AS gold,
+            COUNT(*) FILTER (WHERE r.rank = 2) AS silver,
+            COUNT(*) FILTER (WHERE r.rank = 3) AS bronze,
+            COUNT(*) AS total
+        FROM
+            statistic.reward r
+        JOIN
+            statistic.reward_type rt ON rt.id = r.type
+        JOIN
+            account.user u ON u.id = r.user_id
+        WHERE
+            r.granularity = $3
+            AND ($1::date IS NULL OR r.period_start >= $1::date)
+            AND ($2::date IS NULL OR r.period_end <= $2::date)
+            -- Антинаграды и их варианты по формату игры медалей не приносят
+            AND NOT EXISTS (
+                SELECT 1 FROM unnest($4::text[]) a(code)
+                WHERE rt.code = a.code OR rt.code LIKE replace(a.code, '_', '\_') || '\_%'
+            )
+        GROUP BY
+            u.id, u.first_name, u.last_name, u.number, u.icon
+        ORDER BY
+            gold DESC, silver DESC, bronze DESC, total DESC, u.id;
+    `
+)
+
+// RewardsByPeriod возвращает все награды за период вместе с данными игроков.
+// Названия наград переводятся на язык locale, если для него есть перевод.
+func (db *DB) RewardsByPeriod(ctx context.Context, period Period, locale string) ([]Reward, error) {
+	rows, err := db.querier().Query(ctx, QueryRewardsByPeriod, locale, period.StartDate(), period.EndDate())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query rewards: %w", err)
+	}
+	return scanRewards(rows)
+}
+
+// RewardsByUser возвращает все награды игрока, начиная с последних,
+// с названиями на языке locale.
+func (db *DB) RewardsByUser(ctx context.Context, userID int, locale string) ([]Reward, error) {
+	rows, err := db.querier().Query(ctx, QueryRewardsByUser, locale, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query user rewards: %w", err)
+	}
+	return scanRewards(rows)
+}
+
+// RewardTypes возвращает все типы наград с названиями на языке locale.
+func (db *DB) RewardTypes(ctx context.Context, locale string) ([]RewardType, error) {
+	rows, err := db.querier().Query(ctx, QueryRewardTypes, locale)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query reward types: %w", err)
+	}
+	defer rows.Close()
+
+	types := make([]RewardType, 0)
+	for rows.Next() {
+		var t RewardType
+		if err := rows.Scan(&t.ID, &t.Code, &t.Type, &t.Description); err != nil {
+			return nil, fmt.Errorf("failed to scan reward type: %w", err)
+		}
+		types = append(types, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read reward types: %w", err)
+	}
+	return types, nil
+}
+
+// antiRewardTypes — коды антинаград: место в них не достижение, поэтому они
+// не учитываются в таблице лидеров.
+var antiRewardTypes = []string{
+	WORST_PLAYER_BY_RATING_MONTH,
+	MAX_LOSERATE_MONTH,
+	MAX_LOST_RATING_MONTH,
+	LONGEST_LOSS_STREAK_MONTH,
+}
+
+// Leaderboard возвращает игроков, упорядоченных по количеству медалей за
+// награды длительности granularity: награды за неделю, месяц и год за одни и
+// те же игры не складываются. Антинаграды не учитываются. Если period не nil,
+// учитываются только награды внутри него.
+func (db *DB) Leaderboard(ctx context.Context, period *Period, granularity Granularity) ([]LeaderboardEntry, error) {
+	var start, end *string
+	if period != nil {
+		s, e := period.StartDate(), period.EndDate()
+		start, end = &s, &e
+	}
+
+	rows, err := db.querier().Query(ctx, QueryLeaderboard, start, end, granularity, antiRewardTypes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query leaderboard: %w", err)
+	}
+	defer rows.Close()
+
+	entries := make([]LeaderboardEntry, 0)
+	for rows.Next() {
+		var e LeaderboardEntry
+		if err := rows.Scan(&e.UserID, &e.FirstName, &e.LastName, &e.Number, &e.Icon, &e.Gold, &e.Silver, &e.Bronze, &e.Total); err != nil {
+			return nil, fmt.Errorf("failed to scan leaderboard entry: %w", err)
+		}
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read leaderboard: %w", err)
+	}
+	return entries, nil
+}
+
+func scanRewards(rows pgx.Rows) ([]Reward, error) {
+	defer rows.Close()
+
+	rewards := make([]Reward, 0)
+	for rows.Next() {
+		var r Reward
+		err := rows.Scan(
+			&r.ID, &r.UserID, &r.FirstName, &r.LastName, &r.Number, &r.Icon,
+			&r.TypeID, &r.TypeCode, &r.Type, &r.Description, &r.Rank, &r.Value, &r.Unit, &r.Details, &r.Evidence, &r.Method, &r.SampleSize,
+			&r.PeriodStart, &r.PeriodEnd, &r.Granularity, &r.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan reward: %w", err)
+		}
+		rewards = append(rewards, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read rewards: %w", err)
+	}
+	return rewards, nil
+}
+
+const QueryHasRewards = `
+        SELECT EXISTS (
+            SELECT 1 FROM statistic.reward
+            WHERE period_start = $1 AND period_end = $2
+              AND type IN (` + queryRewardTypeVariants + `)
+        );
+    `
+
+// HasRewards сообщает, сохранен ли за период хотя бы один победитель награды
+// типа rewardType или любого его варианта по формату игры.
+func (db *DB) HasRewards(ctx context.Context, period Period, rewardType string) (bool, error) {
+	var exists bool
+	if err := db.querier().QueryRow(ctx, QueryHasRewards, period.StartDate(), period.EndDate(), rewardType).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check rewards: %w", err)
+	}
+	return exists, nil
+}
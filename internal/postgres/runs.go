@@ -0,0 +1,116 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Статусы запуска награды в журнале statistic.reward_run.
+const (
+	RunStatusRunning    = "running"
+	RunStatusSuccess    = "success"
+	RunStatusFailed     = "failed"
+	RunStatusRolledBack = "rolled_back"
+)
+
+const (
+	QueryStartRun = `
+        INSERT INTO statistic.reward_run (period_start, period_end, granularity, award, started_at, status)
+        VALUES ($1, $2, $3, $4, NOW(), $5)
+        RETURNING id;
+    `
+
+	QueryFinishRun = `
+        UPDATE statistic.reward_run
+        SET finished_at = NOW(), status = $2, error = $3, rows_written = $4
+        WHERE id = $1;
+    `
+
+	QueryRollbackRuns = `
+        UPDATE statistic.reward_run
+        SET status = $2, rows_written = 0
+        WHERE id = ANY($1) AND status = $3;
+    `
+)
+
+// StartRun записывает в журнал начало расчета награды за период. Журнал
+// запусков всегда пишется мимо текущей транзакции, чтобы запись о запуске
+// сохранилась и при откате расчета.
+func (db *DB) StartRun(ctx context.Context, period Period, award string) (int, error) {
+	var runID int
+	err := db.Conn.QueryRow(ctx, QueryStartRun, period.StartDate(), period.EndDate(), period.Granularity, award, RunStatusRunning).Scan(&runID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to start reward run: %w", err)
+	}
+	return runID, nil
+}
+
+// FinishRun записывает в журнал итог расчета награды, как и StartRun, мимо
+// текущей транзакции.
+func (db *DB) FinishRun(ctx context.Context, runID int, status string, runErr error, rowsWritten int) error {
+	var errText *string
+	if runErr != nil {
+		text := runErr.Error()
+		errText = &text
+	}
+	if _, err := db.Conn.Exec(ctx, QueryFinishRun, runID, status, errText, rowsWritten); err != nil {
+		return fmt.Errorf("failed to finish reward run: %w", err)
+	}
+	return nil
+}
+
+// RollbackRuns помечает успешные запуски как откаченные вместе с транзакцией.
+func (db *DB) RollbackRuns(ctx context.Context, runIDs []int) error {
+	if _, err := db.Conn.Exec(ctx, QueryRollbackRuns, runIDs, RunStatusRolledBack, RunStatusSuccess); err != nil {
+		return fmt.Errorf("failed to mark reward runs rolled back: %w", err)
+	}
+	return nil
+}
+
+// QueryLatestRuns выбирает последний запуск каждой награды за период. started_at
+// хранится без часового пояса во времени сессии, поэтому возраст запуска
+// считается от LOCALTIMESTAMP.
+const QueryLatestRuns = `
+        SELECT DISTINCT ON (award)
+            award,
+            status,
+            EXTRACT(EPOCH FROM LOCALTIMESTAMP - started_at)::FLOAT
+        FROM statistic.reward_run
+        WHERE period_start = $1 AND period_end = $2
+        ORDER BY award, started_at DESC, id DESC;
+    `
+
+// LatestRun — последний запуск награды за период.
+type LatestRun struct {
+	Status string
+	// Age — сколько времени прошло с начала запуска.
+	Age time.Duration
+}
+
+// LatestRuns возвращает последний запуск каждой награды за период.
+func (db *DB) LatestRuns(ctx context.Context, period Period) (map[string]LatestRun, error) {
+	rows, err := db.Conn.Query(ctx, QueryLatestRuns, period.StartDate(), period.EndDate())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query reward runs: %w", err)
+	}
+	defer rows.Close()
+
+	runs := make(map[string]LatestRun)
+	for rows.Next() {
+		var (
+			award string
+			run   LatestRun
+			age   float64
+		)
+		if err := rows.Scan(&award, &run.Status, &age); err != nil {
+			return nil, fmt.Errorf("failed to scan reward run: %w", err)
+		}
+		run.Age = time.Duration(age * float64(time.Second))
+		runs[award] = run
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read reward runs: %w", err)
+	}
+	return runs, nil
+}
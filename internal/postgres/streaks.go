@@ -0,0 +1,371 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// StreakKind — какие игры продолжают серию.
+type StreakKind int
+
+const (
+	// StreakWins — серия побед.
+	StreakWins StreakKind = iota
+	// StreakLosses — серия поражений.
+	StreakLosses
+	// StreakUnbeaten — серия игр без поражений: победы и ничьи.
+	StreakUnbeaten
+)
+
+// streakKinds — все виды серий, которые ведутся в личных рекордах.
+var streakKinds = []StreakKind{StreakWins, StreakLosses, StreakUnbeaten}
+
+var streakKindNames = map[StreakKind]string{
+	StreakWins:     "wins",
+	StreakLosses:   "losses",
+	StreakUnbeaten: "unbeaten",
+}
+
+func (k StreakKind) String() string {
+	if name, ok := streakKindNames[k]; ok {
+		return name
+	}
+	return fmt.Sprintf("StreakKind(%d)", int(k))
+}
+
+// ParseStreakKind разбирает вид серии по имени: wins, losses или unbeaten.
+func ParseStreakKind(name string) (StreakKind, error) {
+	for k, n := range streakKindNames {
+		if n == name {
+			return k, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown streak kind %q", name)
+}
+
+// GameResult — результат игры игрока. Ничья — игра без победившей команды.
+type GameResult struct {
+	GameID int
+	Won    bool
+	Lost   bool
+	Time   time.Time
+}
+
+// continues сообщает, продолжает ли игра серию вида k.
+func (k StreakKind) continues(g GameResult) bool {
+	switch k {
+	case StreakLosses:
+		return g.Lost
+	case StreakUnbeaten:
+		return !g.Lost
+	default:
+		return g.Won
+	}
+}
+
+// Streak — серия игр игрока: Games[0] начинает серию, последняя игра ее завершает.
+type Streak struct {
+	Games []GameResult
+}
+
+// Len возвращает длину серии.
+func (s Streak) Len() int { return len(s.Games) }
+
+// StartedAt возвращает время первой игры серии.
+func (s Streak) StartedAt() time.Time { return s.Games[0].Time }
+
+// EndedAt возвращает время последней игры серии.
+func (s Streak) EndedAt() time.Time { return s.Games[len(s.Games)-1].Time }
+
+// GameIDs возвращает игры серии в хронологическом порядке.
+func (s Streak) GameIDs() []int {
+	ids := make([]int, 0, len(s.Games))
+	for _, g := range s.Games {
+		ids = append(ids, g.GameID)
+	}
+	return ids
+}
+
+// LongestStreak возвращает самую длинную серию вида kind среди игр games,
+// отсортированных по времени. Из серий одинаковой длины выбирается более ранняя.
+func LongestStreak(games []GameResult, kind StreakKind) Streak {
+	var longest Streak
+	start := 0
+	for i, g := range games {
+		if !kind.continues(g) {
+			start = i + 1
+			continue
+		}
+		if i+1-start > longest.Len() {
+			longest = Streak{Games: games[start : i+1]}
+		}
+	}
+	return longest
+}
+
+// ActiveStreak возвращает серию вида kind, которая продолжается на последней
+// игре из games, отсортированных по времени.
+func ActiveStreak(games []GameResult, kind StreakKind) Streak {
+	start := len(games)
+	for start > 0 && kind.continues(games[start-1]) {
+		start--
+	}
+	return Streak{Games: games[start:]}
+}
+
+// LongestWinStreak возвращает самую длинную серию побед каждого игрока за период
+// по всем играм и отдельно по каждому формату игры и помечает кандидатов типом
+// награды rewardType. Игроки без побед в результат не попадают.
+func (conn *DB) LongestWinStreak(ctx context.Context, period Period, rewardType string) ([]Candidate, error) {
+	return conn.streaks(ctx, period, rewardType, StreakWins)
+}
+
+// LongestLossStreak возвращает самую длинную серию поражений каждого игрока за
+// период по всем играм и отдельно по каждому формату игры.
+func (conn *DB) LongestLossStreak(ctx context.Context, period Period, rewardType string) ([]Candidate, error) {
+	return conn.streaks(ctx, period, rewardType, StreakLosses)
+}
+
+// LongestUnbeatenRun возвращает самую длинную серию игр без поражений каждого
+// игрока за период по всем играм и отдельно по каждому формату игры.
+func (conn *DB) LongestUnbeatenRun(ctx context.Context, period Period, rewardType string) ([]Candidate, error) {
+	return conn.streaks(ctx, period, rewardType, StreakUnbeaten)
+}
+
+// CurrentWinStreak возвращает серию побед, которая продолжается у игрока на
+// конец периода, по всем играм и отдельно по каждому формату игры. Серия
+// учитывает и игры до начала периода, если она началась раньше.
+func (conn *DB) CurrentWinStreak(ctx context.Context, period Period, rewardType string) ([]Candidate, error) {
+	return conn.activeStreaks(ctx, period, rewardType, StreakWins)
+}
+
+// streaks вычисляет самую длинную серию вида kind каждого игрока за период.
+// Игроки без такой серии в результат не попадают.
+func (conn *DB) streaks(ctx context.Context, period Period, rewardType string, kind StreakKind) (candidates []Candidate, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("recovered from panic: %v", r)
+		}
+	}()
+
+	query := `
+    WITH ` + QueryPeriodGames + `
+    SELECT
+        pg.user_id,
+        pg.game_id,
+        pg.is_winner,
+        EXISTS (
+            SELECT 1 FROM game.team o
+            WHERE o.game_id = pg.game_id AND o.id <> pg.team_id AND o.is_winner
+        ) AS lost,
+        pg.end_time
+    FROM
+        period_games pg
+    WHERE
+        $3::text = '' OR pg.type = $3
+    ORDER BY
+        pg.end_time ASC, pg.game_id ASC -- Важно сортировать по времени в хронологическом порядке
+    `
+
+	candidates, err = conn.byGameType(ctx, period, rewardType, true, func(gameType, rewardType string) ([]Candidate, error) {
+		rows, err := conn.querier().Query(ctx, query, period.Start, period.End, gameType)
+		if err != nil {
+			return nil, fmt.Errorf("failed to execute query: %w", err)
+		}
+		order, results, err := scanGameResults(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		var typeCandidates []Candidate
+		for _, userID := range order {
+			games := results[userID]
+			streak := LongestStreak(games, kind)
+			if streak.Len() == 0 {
+				continue
+			}
+			typeCandidates = append(typeCandidates, Candidate{
+				UserID:     userID,
+				RewardType: rewardType,
+				Value:      float64(streak.Len()),
+				StartedAt:  streak.StartedAt(),
+				AchievedAt: streak.EndedAt(),
+				Games:      len(games),
+				GameIDs:    streak.GameIDs(),
+			})
+		}
+		return typeCandidates, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(candidates) == 0 {
+		log.Printf("No %s found for %s", rewardType, period)
+	}
+
+	return candidates, nil
+}
+
+// activeStreaks вычисляет серию вида kind, которая продолжается у каждого
+// игрока периода на его последней игре периода. Серия прослеживается назад
+// по всем играм игрока до конца периода, поэтому может начаться раньше периода.
+func (conn *DB) activeStreaks(ctx context.Context, period Period, rewardType string, kind StreakKind) (candidates []Candidate, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("recovered from panic: %v", r)
+		}
+	}()
+
+	query := `
+    WITH ` + QueryPeriodGames + `,
+    players AS (
+        SELECT user_id, COUNT(DISTINCT game_id) AS games
+        FROM period_games
+        WHERE $3::text = '' OR type = $3
+        GROUP BY user_id
+    ),
+    player_games AS (
+        -- Все игры игроков периода до конца периода, а не только игры периода
+        SELECT
+            tm.user_id,
+            g.id AS game_id,
+            t.is_winner,
+            EXISTS (
+                SELECT 1 FROM game.team o
+                WHERE o.game_id = g.id AND o.id <> t.id AND o.is_winner
+            ) AS lost,
+            g.end_time
+        FROM
+            players p
+        JOIN
+            game.team_members tm ON tm.user_id = p.user_id
+        JOIN
+            game.team t ON t.id = tm.team_id
+        JOIN
+            game.game g ON g.id = t.game_id
+        WHERE
+            g.end_time IS NOT NULL
+            AND g.end_time < $2
+            AND ($3::text = '' OR g.type = $3)
+    ),
+    marked AS (
+        -- breaks — сколько игр, прерывающих серию, сыграно с этой игры до конца периода
+        SELECT
+            pg.*,
+            COUNT(*) FILTER (WHERE CASE $4::text
+                                       WHEN 'losses' THEN NOT pg.lost
+                                       WHEN 'unbeaten' THEN pg.lost
+                                       ELSE NOT pg.is_winner
+                                   END)
+                OVER (PARTITION BY pg.user_id ORDER BY pg.end_time DESC, pg.game_id DESC) AS breaks
+        FROM
+            player_games pg
+    )
+    SELECT
+        m.user_id,
+        p.games,
+        m.game_id,
+        m.is_winner,
+        m.lost,
+        m.end_time
+    FROM
+        marked m
+    JOIN
+        players p ON p.user_id = m.user_id
+    WHERE
+        m.breaks = 0
+    ORDER BY
+        m.end_time ASC, m.game_id ASC -- Важно сортировать по времени в хронологическом порядке
+    `
+
+	candidates, err = conn.byGameType(ctx, period, rewardType, true, func(gameType, rewardType string) ([]Candidate, error) {
+		rows, err := conn.querier().Query(ctx, query, period.Start, period.End, gameType, kind.String())
+		if err != nil {
+			return nil, fmt.Errorf("failed to execute query: %w", err)
+		}
+		defer rows.Close()
+
+		var (
+			order   []int
+			games   = make(map[int]int)
+			results = make(map[int][]GameResult)
+		)
+		for rows.Next() {
+			var (
+				userID, played int
+				g              GameResult
+			)
+			if err := rows.Scan(&userID, &played, &g.GameID, &g.Won, &g.Lost, &g.Time); err != nil {
+				return nil, fmt.Errorf("failed to scan row: %w", err)
+			}
+			if _, exists := results[userID]; !exists {
+				order = append(order, userID)
+			}
+			games[userID] = played
+			results[userID] = append(results[userID], g)
+		}
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("failed to read rows: %w", err)
+		}
+
+		var typeCandidates []Candidate
+		for _, userID := range order {
+			streak := ActiveStreak(results[userID], kind)
+			// Серия должна продолжаться на игре периода
+			if streak.Len() == 0 || streak.EndedAt().Before(period.Start) {
+				continue
+			}
+			typeCandidates = append(typeCandidates, Candidate{
+				UserID:     userID,
+				RewardType: rewardType,
+				Value:      float64(streak.Len()),
+				StartedAt:  streak.StartedAt(),
+				AchievedAt: streak.EndedAt(),
+				Games:      games[userID],
+				GameIDs:    streak.GameIDs(),
+			})
+		}
+		return typeCandidates, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(candidates) == 0 {
+		log.Printf("No %s found for %s", rewardType, period)
+	}
+
+	return candidates, nil
+}
+
+// scanGameResults читает строки (user_id, game_id, is_winner, lost, end_time),
+// отсортированные по времени игры, и группирует результаты по игрокам.
+// order — игроки в порядке первой игры.
+func scanGameResults(rows pgx.Rows) (order []int, results map[int][]GameResult, err error) {
+	defer rows.Close()
+
+	results = make(map[int][]GameResult)
+	for rows.Next() {
+		var (
+			userID int
+			g      GameResult
+		)
+		if err := rows.Scan(&userID, &g.GameID, &g.Won, &g.Lost, &g.Time); err != nil {
+			return nil, nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		if _, exists := results[userID]; !exists {
+			order = append(order, userID)
+		}
+		// Добавляем результат игры в хронологическом порядке
+		results[userID] = append(results[userID], g)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("failed to read rows: %w", err)
+	}
+	return order, results, nil
+}
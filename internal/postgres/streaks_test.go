@@ -0,0 +1,67 @@
+package postgres
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// results строит игры по строке исходов: W — победа, L — поражение, D — ничья.
+// Игры идут по часу друг за другом, идентификатор игры — ее номер с 1.
+func results(outcomes string) []GameResult {
+	start := time.Date(2025, time.March, 1, 0, 0, 0, 0, time.UTC)
+	games := make([]GameResult, 0, len(outcomes))
+	for i, o := range outcomes {
+		games = append(games, GameResult{
+			GameID: i + 1,
+			Won:    o == 'W',
+			Lost:   o == 'L',
+			Time:   start.Add(time.Duration(i) * time.Hour),
+		})
+	}
+	return games
+}
+
+func TestLongestStreak(t *testing.T) {
+	tests := []struct {
+		name     string
+		outcomes string
+		kind     StreakKind
+		want     []int
+	}{
+		{name: "wins", outcomes: "WWLWWWL", kind: StreakWins, want: []int{4, 5, 6}},
+		{name: "earliest of equal wins", outcomes: "WWLWW", kind: StreakWins, want: []int{1, 2}},
+		{name: "draw breaks wins", outcomes: "WWDWL", kind: StreakWins, want: []int{1, 2}},
+		{name: "losses", outcomes: "LWLLLW", kind: StreakLosses, want: []int{3, 4, 5}},
+		{name: "unbeaten with draws", outcomes: "WLWDWLW", kind: StreakUnbeaten, want: []int{3, 4, 5}},
+		{name: "no wins", outcomes: "LLD", kind: StreakWins, want: []int{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := LongestStreak(results(tt.outcomes), tt.kind).GameIDs(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("LongestStreak() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestActiveStreak(t *testing.T) {
+	tests := []struct {
+		name     string
+		outcomes string
+		kind     StreakKind
+		want     []int
+	}{
+		{name: "ongoing wins", outcomes: "WWWLWW", kind: StreakWins, want: []int{5, 6}},
+		{name: "ended by loss", outcomes: "WWWL", kind: StreakWins, want: []int{}},
+		{name: "ongoing unbeaten", outcomes: "LWDW", kind: StreakUnbeaten, want: []int{2, 3, 4}},
+		{name: "no games", outcomes: "", kind: StreakWins, want: []int{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ActiveStreak(results(tt.outcomes), tt.kind).GameIDs(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ActiveStreak() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
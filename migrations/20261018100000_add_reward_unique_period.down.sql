@@ -0,0 +1,2 @@
+alter table statistic.reward drop constraint if exists reward_period_type_rank_key;
+alter table statistic.reward drop column if exists rank;
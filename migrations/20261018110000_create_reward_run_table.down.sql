@@ -0,0 +1 @@
+drop table if exists statistic.reward_run;
@@ -0,0 +1,13 @@
+create table statistic.reward_run(
+                                     id serial primary key ,
+                                     year varchar(4) not null ,
+                                     month varchar(25) not null ,
+                                     award text not null ,
+                                     started_at timestamp not null default now(),
+                                     finished_at timestamp,
+                                     status text not null , -- running, success, failed, rolled_back
+                                     error text,
+                                     rows_written int not null default 0
+);
+
+create index reward_run_period_award_idx on statistic.reward_run (year, month, award);
@@ -0,0 +1,12 @@
+alter table statistic.reward drop constraint if exists reward_period_type_rank_user_key;
+
+delete from statistic.reward r
+    using statistic.reward newer
+where r.year = newer.year
+  and r.month = newer.month
+  and r.type = newer.type
+  and r.rank = newer.rank
+  and r.id < newer.id;
+
+alter table statistic.reward
+    add constraint reward_period_type_rank_key unique (year, month, type, rank);
@@ -0,0 +1,5 @@
+-- Несколько игроков с одинаковым показателем делят одно место
+alter table statistic.reward drop constraint if exists reward_period_type_rank_key;
+
+alter table statistic.reward
+    add constraint reward_period_type_rank_user_key unique (year, month, type, rank, user_id);
@@ -0,0 +1 @@
+alter table statistic.reward drop constraint if exists reward_rank_positive;
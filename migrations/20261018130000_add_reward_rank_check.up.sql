@@ -0,0 +1,3 @@
+-- Место на пьедестале: 1 — золото, 2 — серебро, 3 — бронза и далее
+alter table statistic.reward
+    add constraint reward_rank_positive check (rank >= 1);
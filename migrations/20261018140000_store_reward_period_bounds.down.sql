@@ -0,0 +1,38 @@
+-- Вернуть в year/month можно только месячные награды
+delete from statistic.reward where granularity <> 'month';
+delete from statistic.reward_run where granularity <> 'month';
+
+alter table statistic.reward
+    add column year varchar(4),
+    add column month varchar(25);
+update statistic.reward
+set year  = to_char(period_start, 'YYYY'),
+    month = to_char(period_start, 'MM');
+alter table statistic.reward
+    alter column year set not null,
+    alter column month set not null;
+
+alter table statistic.reward drop constraint if exists reward_period_type_rank_user_key;
+alter table statistic.reward
+    drop column period_start,
+    drop column period_end,
+    drop column granularity;
+alter table statistic.reward
+    add constraint reward_period_type_rank_user_key unique (year, month, type, rank, user_id);
+
+alter table statistic.reward_run
+    add column year varchar(4),
+    add column month varchar(25);
+update statistic.reward_run
+set year  = to_char(period_start, 'YYYY'),
+    month = to_char(period_start, 'MM');
+alter table statistic.reward_run
+    alter column year set not null,
+    alter column month set not null;
+
+drop index if exists statistic.reward_run_period_award_idx;
+alter table statistic.reward_run
+    drop column period_start,
+    drop column period_end,
+    drop column granularity;
+create index reward_run_period_award_idx on statistic.reward_run (year, month, award);
@@ -0,0 +1,41 @@
+-- Период хранится как полуоткрытый интервал дат [period_start, period_end)
+alter table statistic.reward
+    add column period_start date,
+    add column period_end date,
+    add column granularity varchar(16) not null default 'month';
+
+update statistic.reward
+set period_start = make_date(year::int, month::int, 1),
+    period_end   = (make_date(year::int, month::int, 1) + interval '1 month')::date;
+
+alter table statistic.reward
+    alter column period_start set not null,
+    alter column period_end set not null,
+    alter column granularity drop default;
+
+alter table statistic.reward drop constraint if exists reward_period_type_rank_user_key;
+alter table statistic.reward
+    drop column year,
+    drop column month;
+alter table statistic.reward
+    add constraint reward_period_type_rank_user_key unique (period_start, period_end, type, rank, user_id);
+
+alter table statistic.reward_run
+    add column period_start date,
+    add column period_end date,
+    add column granularity varchar(16) not null default 'month';
+
+update statistic.reward_run
+set period_start = make_date(year::int, month::int, 1),
+    period_end   = (make_date(year::int, month::int, 1) + interval '1 month')::date;
+
+alter table statistic.reward_run
+    alter column period_start set not null,
+    alter column period_end set not null,
+    alter column granularity drop default;
+
+drop index if exists statistic.reward_run_period_award_idx;
+alter table statistic.reward_run
+    drop column year,
+    drop column month;
+create index reward_run_period_award_idx on statistic.reward_run (period_start, period_end, award);
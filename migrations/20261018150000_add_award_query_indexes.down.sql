@@ -0,0 +1,5 @@
+drop index if exists game.team_members_team_id_idx;
+drop index if exists game.team_game_id_idx;
+drop index if exists game.team_created_at_idx;
+drop index if exists game.game_type_end_time_idx;
+drop index if exists game.game_end_time_idx;
@@ -0,0 +1,6 @@
+-- Индексы для выборки игр за полуоткрытый интервал [начало, конец) периода
+create index if not exists game_end_time_idx on game.game (end_time);
+create index if not exists game_type_end_time_idx on game.game (type, end_time);
+create index if not exists team_created_at_idx on game.team (created_at);
+create index if not exists team_game_id_idx on game.team (game_id);
+create index if not exists team_members_team_id_idx on game.team_members (team_id);
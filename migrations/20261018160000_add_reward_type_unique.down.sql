@@ -0,0 +1,2 @@
+alter table statistic.reward_type
+    drop constraint if exists reward_type_type_key;
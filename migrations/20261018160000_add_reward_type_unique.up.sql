@@ -0,0 +1,4 @@
+-- Типы наград для новых форматов игр создаются автоматически, поэтому
+-- название типа должно быть уникальным
+alter table statistic.reward_type
+    add constraint reward_type_type_key unique (type);
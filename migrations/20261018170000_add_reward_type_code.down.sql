@@ -0,0 +1,5 @@
+alter table statistic.reward_type
+    add constraint reward_type_type_key unique (type);
+
+drop index if exists statistic.reward_type_code_key;
+alter table statistic.reward_type drop column if exists code;
@@ -0,0 +1,34 @@
+-- Стабильный код типа награды вместо поиска по названию
+alter table statistic.reward_type add column code text;
+
+-- Коды встроенных наград. Награды по формату игры получают код с суффиксом
+-- формата: "Лучший процент побед за месяц 1x1!" -> top_winrate_1x1
+with base (code, title) as (
+    values ('best_rating', 'Лучший игрок месяца по рейтингу'),
+           ('worst_rating', 'Худший игрок месяца по рейтингу'),
+           ('top_winrate', 'Лучший процент побед за месяц'),
+           ('bottom_winrate', 'Худший процент побед за месяц'),
+           ('top_gained_rating', 'Максимальный прирост рейтинга за месяц'),
+           ('top_lost_rating', 'Максимальная потеря рейтинга за месяц'),
+           ('max_games_played', 'Наибольшее количество сыгранных игр за месяц'),
+           ('longest_win_streak', 'Самая длинная серия подряд за месяц')
+)
+update statistic.reward_type rt
+set code = case
+               when rt.type = b.title || '!' then b.code
+               else b.code || '_' || substr(rt.type, length(b.title) + 2, length(rt.type) - length(b.title) - 2)
+           end
+from base b
+where rt.type = b.title || '!'
+   or (rt.type like b.title || ' %' and rt.type like '%!');
+
+-- Остальным типам код выдается по идентификатору
+update statistic.reward_type
+set code = 'reward_type_' || id
+where code is null;
+
+alter table statistic.reward_type alter column code set not null;
+create unique index reward_type_code_key on statistic.reward_type (code);
+
+-- Название больше не ключ типа награды
+alter table statistic.reward_type drop constraint if exists reward_type_type_key;
@@ -0,0 +1,3 @@
+alter table statistic.reward
+    drop column if exists sample_size,
+    drop column if exists method;
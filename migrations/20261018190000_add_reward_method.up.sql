@@ -0,0 +1,4 @@
+-- Способ оценки значения награды и количество игр, по которым оно посчитано
+alter table statistic.reward
+    add column method text,
+    add column sample_size int;
@@ -0,0 +1,9 @@
+alter table statistic.reward add column value_text text;
+
+update statistic.reward set value_text = value::text;
+
+alter table statistic.reward
+    drop column value,
+    drop column unit,
+    drop column details;
+alter table statistic.reward rename column value_text to value;
@@ -0,0 +1,28 @@
+-- Числовое значение награды вместо текста, единица измерения и подробности
+alter table statistic.reward
+    add column value_numeric numeric,
+    add column unit text,
+    add column details jsonb not null default '{}';
+
+update statistic.reward
+set value_numeric = value::numeric
+where value ~ '^\s*-?[0-9]+(\.[0-9]+)?([eE][-+]?[0-9]+)?\s*$';
+
+alter table statistic.reward drop column value;
+alter table statistic.reward rename column value_numeric to value;
+
+-- Единица измерения и формат игры по коду типа награды
+update statistic.reward r
+set unit = case
+               when rt.code like 'best\_rating%' or rt.code like 'worst\_rating%' then 'rating'
+               when rt.code like 'top\_winrate%' or rt.code like 'bottom\_winrate%' then 'ratio'
+               when rt.code like 'top\_gained\_rating%' or rt.code like 'top\_lost\_rating%' then 'rating_points'
+               when rt.code like 'max\_games\_played%' then 'games'
+               when rt.code like 'longest\_win\_streak%' then 'wins'
+           end,
+    details = jsonb_strip_nulls(jsonb_build_object(
+        'games', r.sample_size,
+        'game_type', substring(rt.code from '^(?:best_rating|worst_rating|top_winrate|bottom_winrate|top_gained_rating|top_lost_rating|max_games_played|longest_win_streak)_(.+)$')
+    ))
+from statistic.reward_type rt
+where rt.id = r.type;
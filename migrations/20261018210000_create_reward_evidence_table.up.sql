@@ -0,0 +1,10 @@
+-- Игры, подтверждающие награду: игры серии побед, игра с итоговым рейтингом,
+-- игры, учтенные в проценте побед
+create table statistic.reward_evidence (
+    reward_id int not null references statistic.reward (id) on delete cascade,
+    game_id   int not null references game.game (id) on delete cascade,
+    position  int not null,
+    primary key (reward_id, game_id)
+);
+
+create index reward_evidence_game_id_idx on statistic.reward_evidence (game_id);
@@ -0,0 +1,14 @@
+-- Награды за серии удаляются вместе с типами, переводы удаляются каскадно
+with streak_types as (
+    select id
+    from statistic.reward_type
+    where code in ('longest_loss_streak', 'longest_unbeaten_run', 'current_win_streak')
+       or code like 'longest\_loss\_streak\_%'
+       or code like 'longest\_unbeaten\_run\_%'
+       or code like 'current\_win\_streak\_%'
+), deleted as (
+    delete from statistic.reward
+    where type in (select id from streak_types)
+)
+delete from statistic.reward_type
+where id in (select id from streak_types);
@@ -0,0 +1,15 @@
+-- Типы наград за серии поражений, серии без поражений и текущие серии побед
+insert into statistic.reward_type (code, type)
+values ('longest_loss_streak', 'Самая длинная серия поражений за месяц!'),
+       ('longest_unbeaten_run', 'Самая длинная серия без поражений за месяц!'),
+       ('current_win_streak', 'Самая длинная текущая серия побед!')
+on conflict (code) do nothing;
+
+insert into statistic.reward_type_translation (code, locale, title, description)
+values ('longest_loss_streak', 'ru', 'Самая длинная серия поражений за месяц!', 'Самая длинная серия поражений подряд за период.'),
+       ('longest_loss_streak', 'en', 'Longest losing streak of the month!', 'Longest run of consecutive losses over the period.'),
+       ('longest_unbeaten_run', 'ru', 'Самая длинная серия без поражений за месяц!', 'Самая длинная серия игр подряд без поражений за период, ничьи ее не прерывают.'),
+       ('longest_unbeaten_run', 'en', 'Longest unbeaten run of the month!', 'Longest run of consecutive games without a loss over the period, draws included.'),
+       ('current_win_streak', 'ru', 'Самая длинная текущая серия побед!', 'Серия побед подряд, которая продолжается на конец периода.'),
+       ('current_win_streak', 'en', 'Longest active win streak!', 'Run of consecutive wins still going at the end of the period.')
+on conflict (code, locale) do nothing;
@@ -0,0 +1,17 @@
+-- Награды за рекорды удаляются вместе с типами, переводы удаляются каскадно
+with record_types as (
+    select id
+    from statistic.reward_type
+    where code in ('win_streak_record', 'unbeaten_run_record')
+       or code like 'win\_streak\_record\_%'
+       or code like 'unbeaten\_run\_record\_%'
+), deleted as (
+    delete from statistic.reward
+    where type in (select id from record_types)
+)
+delete from statistic.reward_type
+where id in (select id from record_types);
+
+drop table if exists statistic.streak_progress;
+drop table if exists statistic.streak_state;
+drop table if exists statistic.streak_record;
@@ -0,0 +1,49 @@
+-- Личные рекорды серий игроков за всё время. Каждая строка — момент, когда
+-- серия игрока впервые достигла длины length, превысив его прежний рекорд,
+-- поэтому рекорд на любую дату восстанавливается по achieved_at.
+-- game_type пустой для серий по всем играм.
+create table statistic.streak_record (
+    user_id     int         not null references account.user (id),
+    kind        text        not null,
+    game_type   text        not null default '',
+    length      int         not null,
+    started_at  timestamptz not null,
+    achieved_at timestamptz not null,
+    game_ids    int[]       not null,
+    primary key (user_id, kind, game_type, length)
+);
+
+create index streak_record_kind_achieved_at_idx on statistic.streak_record (kind, game_type, achieved_at);
+
+-- Текущие серии игроков, которые продолжатся следующими играми
+create table statistic.streak_state (
+    user_id    int         not null references account.user (id),
+    kind       text        not null,
+    game_type  text        not null default '',
+    started_at timestamptz not null,
+    ended_at   timestamptz not null,
+    game_ids   int[]       not null,
+    primary key (user_id, kind, game_type)
+);
+
+-- Последняя учтенная в сериях игра. Таблица всегда содержит одну строку
+create table statistic.streak_progress (
+    id       boolean     primary key default true check (id),
+    end_time timestamptz not null,
+    game_id  int         not null
+);
+
+insert into statistic.streak_progress (end_time, game_id) values (to_timestamp(0), 0);
+
+-- Награды за новый рекорд лиги
+insert into statistic.reward_type (code, type)
+values ('win_streak_record', 'Новый рекорд серии побед!'),
+       ('unbeaten_run_record', 'Новый рекорд серии без поражений!')
+on conflict (code) do nothing;
+
+insert into statistic.reward_type_translation (code, locale, title, description)
+values ('win_streak_record', 'ru', 'Новый рекорд серии побед!', 'Серия побед подряд длиннее любой серии в истории лиги, в том числе через границы месяцев.'),
+       ('win_streak_record', 'en', 'New win streak record!', 'Win streak longer than any in league history, including streaks spanning months.'),
+       ('unbeaten_run_record', 'ru', 'Новый рекорд серии без поражений!', 'Серия игр без поражений длиннее любой серии в истории лиги, в том числе через границы месяцев.'),
+       ('unbeaten_run_record', 'en', 'New unbeaten run record!', 'Unbeaten run longer than any in league history, including runs spanning months.')
+on conflict (code, locale) do nothing;
@@ -0,0 +1,5 @@
+-- Базовые типы наград за рейтинг, которые создала миграция и которые не
+-- использовались. Их переводы удаляются каскадно
+delete from statistic.reward_type rt
+where rt.code in ('best_rating', 'worst_rating')
+  and not exists (select 1 from statistic.reward r where r.type = rt.id);
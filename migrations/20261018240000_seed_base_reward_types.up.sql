@@ -0,0 +1,41 @@
+-- Переводы создавались только для типов наград, которые уже были в базе, а
+-- награды за рейтинг сохраняются только по форматам игры, поэтому их базовых
+-- типов может не быть. Переводы наград по формату игры строятся из переводов
+-- базовой награды, поэтому базовые типы создаются для всех встроенных наград
+insert into statistic.reward_type (code, type)
+values ('best_rating', 'Лучший игрок месяца по рейтингу!'),
+       ('worst_rating', 'Худший игрок месяца по рейтингу!'),
+       ('top_winrate', 'Лучший процент побед за месяц!'),
+       ('bottom_winrate', 'Худший процент побед за месяц!'),
+       ('top_gained_rating', 'Максимальный прирост рейтинга за месяц!'),
+       ('top_lost_rating', 'Максимальная потеря рейтинга за месяц!'),
+       ('max_games_played', 'Наибольшее количество сыгранных игр за месяц!'),
+       ('longest_win_streak', 'Самая длинная серия подряд за месяц!')
+on conflict (code) do nothing;
+
+insert into statistic.reward_type_translation (code, locale, title, description)
+values ('best_rating', 'ru', 'Лучший игрок месяца по рейтингу!', 'Самый высокий рейтинг по итогам последней игры периода.'),
+       ('best_rating', 'en', 'Best player of the month by rating!', 'Highest rating after the last game of the period.'),
+       ('worst_rating', 'ru', 'Худший игрок месяца по рейтингу!', 'Самый низкий рейтинг по итогам последней игры периода.'),
+       ('worst_rating', 'en', 'Worst player of the month by rating!', 'Lowest rating after the last game of the period.'),
+       ('top_winrate', 'ru', 'Лучший процент побед за месяц!', 'Наибольшая доля побед среди игроков, сыгравших не меньше 10 игр.'),
+       ('top_winrate', 'en', 'Best win rate of the month!', 'Highest share of wins among players with at least 10 games.'),
+       ('bottom_winrate', 'ru', 'Худший процент побед за месяц!', 'Наименьшая доля побед среди игроков, сыгравших не меньше 10 игр.'),
+       ('bottom_winrate', 'en', 'Worst win rate of the month!', 'Lowest share of wins among players with at least 10 games.'),
+       ('top_gained_rating', 'ru', 'Максимальный прирост рейтинга за месяц!', 'Наибольшее суммарное изменение рейтинга за период.'),
+       ('top_gained_rating', 'en', 'Biggest rating gain of the month!', 'Largest total rating change over the period.'),
+       ('top_lost_rating', 'ru', 'Максимальная потеря рейтинга за месяц!', 'Наибольшая суммарная потеря рейтинга за период.'),
+       ('top_lost_rating', 'en', 'Biggest rating loss of the month!', 'Largest total rating loss over the period.'),
+       ('max_games_played', 'ru', 'Наибольшее количество сыгранных игр за месяц!', 'Больше всего завершенных игр за период.'),
+       ('max_games_played', 'en', 'Most games played this month!', 'Most finished games over the period.'),
+       ('longest_win_streak', 'ru', 'Самая длинная серия подряд за месяц!', 'Самая длинная серия побед подряд за период.'),
+       ('longest_win_streak', 'en', 'Longest win streak of the month!', 'Longest run of consecutive wins over the period.')
+on conflict (code, locale) do nothing;
+
+-- Переводы наград по формату игры, пропущенные из-за отсутствия базового типа:
+-- best_rating_1x1 получает "Best player of the month by rating 1x1!"
+insert into statistic.reward_type_translation (code, locale, title, description)
+select rt.code, tr.locale, rtrim(tr.title, '!') || ' ' || substr(rt.code, length(tr.code) + 2) || '!', tr.description
+from statistic.reward_type rt
+join statistic.reward_type_translation tr on rt.code like replace(tr.code, '_', '\_') || '\_%'
+on conflict (code, locale) do nothing;
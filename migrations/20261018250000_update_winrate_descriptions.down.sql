@@ -0,0 +1,17 @@
+update statistic.reward_type_translation
+set description = case locale
+                      when 'ru' then 'Наибольшая доля побед среди игроков, сыгравших не меньше 10 игр.'
+                      else 'Highest share of wins among players with at least 10 games.'
+                  end
+where (code = 'top_winrate' or code like 'top\_winrate\_%')
+  and description in ('Наибольшая доля побед среди игроков, сыгравших за период минимальное количество игр.',
+                      'Highest share of wins among players with the minimum number of games in the period.');
+
+update statistic.reward_type_translation
+set description = case locale
+                      when 'ru' then 'Наименьшая доля побед среди игроков, сыгравших не меньше 10 игр.'
+                      else 'Lowest share of wins among players with at least 10 games.'
+                  end
+where (code = 'bottom_winrate' or code like 'bottom\_winrate\_%')
+  and description in ('Наименьшая доля побед среди игроков, сыгравших за период минимальное количество игр.',
+                      'Lowest share of wins among players with the minimum number of games in the period.');
@@ -0,0 +1,19 @@
+-- Порог по количеству игр для наград за процент побед настраивается, поэтому
+-- описания не называют конкретное число игр. Обновляются и награды по формату игры
+update statistic.reward_type_translation
+set description = case locale
+                      when 'ru' then 'Наибольшая доля побед среди игроков, сыгравших за период минимальное количество игр.'
+                      else 'Highest share of wins among players with the minimum number of games in the period.'
+                  end
+where (code = 'top_winrate' or code like 'top\_winrate\_%')
+  and description in ('Наибольшая доля побед среди игроков, сыгравших не меньше 10 игр.',
+                      'Highest share of wins among players with at least 10 games.');
+
+update statistic.reward_type_translation
+set description = case locale
+                      when 'ru' then 'Наименьшая доля побед среди игроков, сыгравших за период минимальное количество игр.'
+                      else 'Lowest share of wins among players with the minimum number of games in the period.'
+                  end
+where (code = 'bottom_winrate' or code like 'bottom\_winrate\_%')
+  and description in ('Наименьшая доля побед среди игроков, сыгравших не меньше 10 игр.',
+                      'Lowest share of wins among players with at least 10 games.');
@@ -0,0 +1,46 @@
+create temporary table reward_title_rename (
+    code      text not null,
+    locale    text not null,
+    old_title text not null,
+    new_title text not null
+);
+
+insert into reward_title_rename (code, locale, old_title, new_title)
+values ('best_rating', 'ru', 'Лучший игрок по рейтингу!', 'Лучший игрок месяца по рейтингу!'),
+       ('best_rating', 'en', 'Best player by rating!', 'Best player of the month by rating!'),
+       ('worst_rating', 'ru', 'Худший игрок по рейтингу!', 'Худший игрок месяца по рейтингу!'),
+       ('worst_rating', 'en', 'Worst player by rating!', 'Worst player of the month by rating!'),
+       ('top_winrate', 'ru', 'Лучший процент побед!', 'Лучший процент побед за месяц!'),
+       ('top_winrate', 'en', 'Best win rate!', 'Best win rate of the month!'),
+       ('bottom_winrate', 'ru', 'Худший процент побед!', 'Худший процент побед за месяц!'),
+       ('bottom_winrate', 'en', 'Worst win rate!', 'Worst win rate of the month!'),
+       ('top_gained_rating', 'ru', 'Максимальный прирост рейтинга!', 'Максимальный прирост рейтинга за месяц!'),
+       ('top_gained_rating', 'en', 'Biggest rating gain!', 'Biggest rating gain of the month!'),
+       ('top_lost_rating', 'ru', 'Максимальная потеря рейтинга!', 'Максимальная потеря рейтинга за месяц!'),
+       ('top_lost_rating', 'en', 'Biggest rating loss!', 'Biggest rating loss of the month!'),
+       ('max_games_played', 'ru', 'Наибольшее количество сыгранных игр!', 'Наибольшее количество сыгранных игр за месяц!'),
+       ('max_games_played', 'en', 'Most games played!', 'Most games played this month!'),
+       ('longest_win_streak', 'ru', 'Самая длинная серия побед!', 'Самая длинная серия подряд за месяц!'),
+       ('longest_win_streak', 'en', 'Longest win streak!', 'Longest win streak of the month!'),
+       ('longest_loss_streak', 'ru', 'Самая длинная серия поражений!', 'Самая длинная серия поражений за месяц!'),
+       ('longest_loss_streak', 'en', 'Longest losing streak!', 'Longest losing streak of the month!'),
+       ('longest_unbeaten_run', 'ru', 'Самая длинная серия без поражений!', 'Самая длинная серия без поражений за месяц!'),
+       ('longest_unbeaten_run', 'en', 'Longest unbeaten run!', 'Longest unbeaten run of the month!');
+
+-- Названия наград по формату игры отличаются суффиксом формата: "... 1x1!".
+-- Переименовываются только названия, которые не меняли вручную
+update statistic.reward_type rt
+set type = rtrim(r.new_title, '!') || substr(rt.type, length(rtrim(r.old_title, '!')) + 1)
+from reward_title_rename r
+where r.locale = 'ru'
+  and (rt.code = r.code or rt.code like replace(r.code, '_', '\_') || '\_%')
+  and (rt.type = r.old_title or rt.type like rtrim(r.old_title, '!') || ' %!');
+
+update statistic.reward_type_translation tr
+set title = rtrim(r.new_title, '!') || substr(tr.title, length(rtrim(r.old_title, '!')) + 1)
+from reward_title_rename r
+where tr.locale = r.locale
+  and (tr.code = r.code or tr.code like replace(r.code, '_', '\_') || '\_%')
+  and (tr.title = r.old_title or tr.title like rtrim(r.old_title, '!') || ' %!');
+
+drop table reward_title_rename;
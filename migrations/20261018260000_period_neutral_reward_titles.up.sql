@@ -0,0 +1,48 @@
+-- Награды считаются за неделю, месяц, квартал, год и сезон, поэтому названия
+-- типов наград не называют период: он хранится в награде отдельно
+create temporary table reward_title_rename (
+    code      text not null,
+    locale    text not null,
+    old_title text not null,
+    new_title text not null
+);
+
+insert into reward_title_rename (code, locale, old_title, new_title)
+values ('best_rating', 'ru', 'Лучший игрок месяца по рейтингу!', 'Лучший игрок по рейтингу!'),
+       ('best_rating', 'en', 'Best player of the month by rating!', 'Best player by rating!'),
+       ('worst_rating', 'ru', 'Худший игрок месяца по рейтингу!', 'Худший игрок по рейтингу!'),
+       ('worst_rating', 'en', 'Worst player of the month by rating!', 'Worst player by rating!'),
+       ('top_winrate', 'ru', 'Лучший процент побед за месяц!', 'Лучший процент побед!'),
+       ('top_winrate', 'en', 'Best win rate of the month!', 'Best win rate!'),
+       ('bottom_winrate', 'ru', 'Худший процент побед за месяц!', 'Худший процент побед!'),
+       ('bottom_winrate', 'en', 'Worst win rate of the month!', 'Worst win rate!'),
+       ('top_gained_rating', 'ru', 'Максимальный прирост рейтинга за месяц!', 'Максимальный прирост рейтинга!'),
+       ('top_gained_rating', 'en', 'Biggest rating gain of the month!', 'Biggest rating gain!'),
+       ('top_lost_rating', 'ru', 'Максимальная потеря рейтинга за месяц!', 'Максимальная потеря рейтинга!'),
+       ('top_lost_rating', 'en', 'Biggest rating loss of the month!', 'Biggest rating loss!'),
+       ('max_games_played', 'ru', 'Наибольшее количество сыгранных игр за месяц!', 'Наибольшее количество сыгранных игр!'),
+       ('max_games_played', 'en', 'Most games played this month!', 'Most games played!'),
+       ('longest_win_streak', 'ru', 'Самая длинная серия подряд за месяц!', 'Самая длинная серия побед!'),
+       ('longest_win_streak', 'en', 'Longest win streak of the month!', 'Longest win streak!'),
+       ('longest_loss_streak', 'ru', 'Самая длинная серия поражений за месяц!', 'Самая длинная серия поражений!'),
+       ('longest_loss_streak', 'en', 'Longest losing streak of the month!', 'Longest losing streak!'),
+       ('longest_unbeaten_run', 'ru', 'Самая длинная серия без поражений за месяц!', 'Самая длинная серия без поражений!'),
+       ('longest_unbeaten_run', 'en', 'Longest unbeaten run of the month!', 'Longest unbeaten run!');
+
+-- Названия наград по формату игры отличаются суффиксом формата: "... 1x1!".
+-- Переименовываются только названия, которые не меняли вручную
+update statistic.reward_type rt
+set type = rtrim(r.new_title, '!') || substr(rt.type, length(rtrim(r.old_title, '!')) + 1)
+from reward_title_rename r
+where r.locale = 'ru'
+  and (rt.code = r.code or rt.code like replace(r.code, '_', '\_') || '\_%')
+  and (rt.type = r.old_title or rt.type like rtrim(r.old_title, '!') || ' %!');
+
+update statistic.reward_type_translation tr
+set title = rtrim(r.new_title, '!') || substr(tr.title, length(rtrim(r.old_title, '!')) + 1)
+from reward_title_rename r
+where tr.locale = r.locale
+  and (tr.code = r.code or tr.code like replace(r.code, '_', '\_') || '\_%')
+  and (tr.title = r.old_title or tr.title like rtrim(r.old_title, '!') || ' %!');
+
+drop table reward_title_rename;
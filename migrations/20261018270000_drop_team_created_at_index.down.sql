@@ -0,0 +1 @@
+create index if not exists team_created_at_idx on game.team (created_at);
@@ -0,0 +1,3 @@
+-- Награды выбирают игры по game.end_time, а команды соединяют по game_id,
+-- поэтому индекс по времени создания команды ни одним запросом не используется
+drop index if exists game.team_created_at_idx;